@@ -0,0 +1,384 @@
+package staticcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"honnef.co/go/lint"
+	. "honnef.co/go/staticcheck/lintdsl"
+)
+
+// Call wraps a single call expression together with enough context
+// (the *lint.File it occurs in and its resolved *types.Func) for a
+// CallRule to validate its arguments.
+type Call struct {
+	File *lint.File
+	Expr *ast.CallExpr
+	Func *types.Func
+}
+
+// Arg returns the i'th argument expression of the call, or nil if the call
+// doesn't have that many arguments.
+func (c *Call) Arg(i int) *Argument {
+	if i < 0 {
+		i = len(c.Expr.Args) + i
+	}
+	if i < 0 || i >= len(c.Expr.Args) {
+		return nil
+	}
+	return &Argument{call: c, Expr: c.Expr.Args[i]}
+}
+
+// Argument is a single argument of a Call. Use one of its accessors to
+// look at its value, or Invalid to report a problem with it.
+type Argument struct {
+	call *Call
+	Expr ast.Expr
+}
+
+// Invalid reports msg at the position of the argument.
+func (a *Argument) Invalid(format string, args ...interface{}) {
+	a.call.File.Errorf(a.Expr, format, args...)
+}
+
+// ConstantString returns the argument's value, if it is a constant string.
+func (a *Argument) ConstantString() (string, bool) {
+	return constantString(a.call.File, a.Expr)
+}
+
+// Type returns the static type of the argument.
+func (a *Argument) Type() types.Type {
+	return a.call.File.Pkg.TypesInfo.TypeOf(a.Expr)
+}
+
+// CallRule validates the arguments of a single call expression, reporting
+// problems via call.Arg(i).Invalid(...).
+type CallRule func(call *Call)
+
+// calleeName resolves the full name of the function being called, as
+// returned by (*types.Func).FullName, looking through both
+// "pkg.Func(...)" and dot-imported "Func(...)" call forms.
+func calleeName(f *lint.File, call *ast.CallExpr) (string, *types.Func) {
+	var obj types.Object
+	switch fun := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		obj = f.Pkg.TypesInfo.ObjectOf(fun.Sel)
+	case *ast.Ident:
+		obj = f.Pkg.TypesInfo.ObjectOf(fun)
+	default:
+		return "", nil
+	}
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return "", nil
+	}
+	return fn.FullName(), fn
+}
+
+// checkCalls walks f, dispatching every call expression whose callee has a
+// rule in rules. This replaces a family of bespoke ast.Walk functions -- one
+// per check -- with a single walker plus a table of (function name ->
+// validator) entries: adding a new argument check becomes one map entry and
+// a CallRule, not a new AST walker.
+func checkCalls(f *lint.File, rules map[string]CallRule) {
+	fn := func(node ast.Node) bool {
+		ce, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		name, fnObj := calleeName(f, ce)
+		if fnObj == nil {
+			return true
+		}
+		rule, ok := rules[name]
+		if !ok {
+			return true
+		}
+		rule(&Call{File: f, Expr: ce, Func: fnObj})
+		return true
+	}
+	f.Walk(fn)
+}
+
+// validRegexp returns a CallRule flagging argument idx when it is a
+// constant string that isn't a valid regular expression.
+func validRegexp(idx int) CallRule {
+	return func(call *Call) {
+		arg := call.Arg(idx)
+		if arg == nil {
+			return
+		}
+		s, ok := arg.ConstantString()
+		if !ok {
+			return
+		}
+		if _, err := regexp.Compile(s); err != nil {
+			arg.Invalid("%s", err)
+		}
+	}
+}
+
+// validURL returns a CallRule flagging argument idx when it is a constant
+// string that isn't a valid URL.
+func validURL(idx int) CallRule {
+	return func(call *Call) {
+		arg := call.Arg(idx)
+		if arg == nil {
+			return
+		}
+		s, ok := arg.ConstantString()
+		if !ok {
+			return
+		}
+		if _, err := url.Parse(s); err != nil {
+			arg.Invalid("invalid argument to %s: %s", call.Func.Name(), err)
+		}
+	}
+}
+
+// validTimeLayout returns a CallRule flagging argument idx when it is a
+// constant string that isn't a valid time.Parse/time.Format reference
+// layout.
+func validTimeLayout(idx int) CallRule {
+	return func(call *Call) {
+		arg := call.Arg(idx)
+		if arg == nil {
+			return
+		}
+		s, ok := arg.ConstantString()
+		if !ok {
+			return
+		}
+		layout := strings.Replace(s, "_", " ", -1)
+		layout = strings.Replace(layout, "Z", "-", -1)
+		if _, err := time.Parse(layout, layout); err != nil {
+			arg.Invalid("%s", err)
+		}
+	}
+}
+
+// notShellCommand returns a CallRule flagging argument idx when it is a
+// constant string that looks like a shell command line rather than a
+// program name or path.
+func notShellCommand(idx int) CallRule {
+	return func(call *Call) {
+		arg := call.Arg(idx)
+		if arg == nil {
+			return
+		}
+		s, ok := arg.ConstantString()
+		if !ok {
+			return
+		}
+		if !strings.Contains(s, " ") || strings.Contains(s, `\`) {
+			return
+		}
+		arg.Invalid("first argument to %s looks like a shell command, but a program name or path are expected", call.Func.Name())
+	}
+}
+
+// validUTF8Cutset returns a CallRule flagging argument idx when it is a
+// constant string that isn't valid UTF-8.
+func validUTF8Cutset(idx int) CallRule {
+	return func(call *Call) {
+		arg := call.Arg(idx)
+		if arg == nil {
+			return
+		}
+		s, ok := arg.ConstantString()
+		if !ok {
+			return
+		}
+		if !utf8.ValidString(s) {
+			arg.Invalid("the argument to %s should be a valid UTF-8 encoded string", fmt.Sprintf("%s.%s", call.Func.Pkg().Name(), call.Func.Name()))
+		}
+	}
+}
+
+// combine returns a CallRule running each of rules in turn.
+func combine(rules ...CallRule) CallRule {
+	return func(call *Call) {
+		for _, rule := range rules {
+			rule(call)
+		}
+	}
+}
+
+// uniqueCutset returns a CallRule flagging argument idx when it is a
+// constant string containing the same rune more than once, such as
+// strings.Trim(s, "  ") or strings.Trim(s, "ab a") -- a cutset is a set,
+// not a substring, so a repeated rune is almost always a typo for the
+// string the caller actually meant to trim.
+func uniqueCutset(idx int) CallRule {
+	return func(call *Call) {
+		arg := call.Arg(idx)
+		if arg == nil {
+			return
+		}
+		s, ok := arg.ConstantString()
+		if !ok {
+			return
+		}
+		if r, ok := duplicateRune(s); ok {
+			arg.Invalid("argument to %s has duplicate rune %q", call.Func.Name(), r)
+		}
+	}
+}
+
+// duplicateRune reports the first rune, in order of appearance, that
+// occurs more than once in s. Runes are decoded with
+// utf8.DecodeRuneInString rather than a []rune conversion so that an
+// invalid encoding only ever contributes utf8.RuneError once per bad
+// byte, same as ranging over the string would.
+func duplicateRune(s string) (rune, bool) {
+	seen := make(map[rune]bool, len(s))
+	for i, w := 0, 0; i < len(s); i += w {
+		r, width := utf8.DecodeRuneInString(s[i:])
+		w = width
+		if seen[r] {
+			return r, true
+		}
+		seen[r] = true
+	}
+	return 0, false
+}
+
+// pointerArg returns a CallRule flagging argument idx (negative indices
+// count from the end, so -1 is the last argument) when its static type is
+// neither a pointer nor an interface.
+func pointerArg(idx int) CallRule {
+	return func(call *Call) {
+		arg := call.Arg(idx)
+		if arg == nil {
+			return
+		}
+		typ := arg.Type()
+		if typ == nil {
+			return
+		}
+		if IsPointerLike(typ) {
+			return
+		}
+		arg.Invalid("%s expects to unmarshal into a pointer, but the provided value is not a pointer", call.Func.Name())
+	}
+}
+
+// ArgumentRule is a named, reusable check against a single argument of a
+// call, for declaring a CallCheck as a plain list instead of a closure --
+// e.g. map["strings.Trim"] = Args(ValidUTF8{1}, UniqueCutset{1}) reads the
+// same as the rule it enforces.
+type ArgumentRule interface {
+	asCallRule() CallRule
+}
+
+// Args combines a list of ArgumentRules, keyed by which argument they
+// apply to, into a single CallRule that runs all of them.
+func Args(rules ...ArgumentRule) CallRule {
+	crs := make([]CallRule, len(rules))
+	for i, r := range rules {
+		crs[i] = r.asCallRule()
+	}
+	return combine(crs...)
+}
+
+// ValidRegexp flags argument Idx when it is a constant string that isn't
+// a valid regular expression.
+type ValidRegexp struct{ Idx int }
+
+func (r ValidRegexp) asCallRule() CallRule { return validRegexp(r.Idx) }
+
+// ValidURL flags argument Idx when it is a constant string that isn't a
+// valid URL.
+type ValidURL struct{ Idx int }
+
+func (r ValidURL) asCallRule() CallRule { return validURL(r.Idx) }
+
+// ValidUTF8 flags argument Idx when it is a constant string that isn't
+// valid UTF-8.
+type ValidUTF8 struct{ Idx int }
+
+func (r ValidUTF8) asCallRule() CallRule { return validUTF8Cutset(r.Idx) }
+
+// UniqueCutset flags argument Idx when it is a constant string containing
+// the same rune more than once.
+type UniqueCutset struct{ Idx int }
+
+func (r UniqueCutset) asCallRule() CallRule { return uniqueCutset(r.Idx) }
+
+// Pointer flags argument Idx when its static type is neither a pointer
+// nor an interface.
+type Pointer struct{ Idx int }
+
+func (r Pointer) asCallRule() CallRule { return pointerArg(r.Idx) }
+
+// NonNegative flags argument Idx when it is a constant integer that is
+// negative.
+type NonNegative struct{ Idx int }
+
+func (r NonNegative) asCallRule() CallRule {
+	return func(call *Call) {
+		arg := call.Arg(r.Idx)
+		if arg == nil {
+			return
+		}
+		tv := call.File.Pkg.TypesInfo.Types[arg.Expr]
+		if tv.Value == nil || tv.Value.Kind() != constant.Int {
+			return
+		}
+		if n, ok := constant.Int64Val(tv.Value); ok && n < 0 {
+			arg.Invalid("argument to %s should not be negative", call.Func.Name())
+		}
+	}
+}
+
+// NotUntypedNil flags argument Idx when it is the untyped nil literal --
+// useful for parameters, such as context.Context, where callers have a
+// valid non-nil zero value they should pass instead.
+type NotUntypedNil struct{ Idx int }
+
+func (r NotUntypedNil) asCallRule() CallRule {
+	return func(call *Call) {
+		arg := call.Arg(r.Idx)
+		if arg == nil {
+			return
+		}
+		typ, ok := arg.Type().(*types.Basic)
+		if !ok || typ.Kind() != types.UntypedNil {
+			return
+		}
+		sig := call.Func.Type().(*types.Signature)
+		arg.Invalid("do not pass a nil %s", sig.Params().At(r.Idx).Type())
+	}
+}
+
+// checkMethodCalls walks f, dispatching every call to a method whose name
+// satisfies match -- and, if typeName is non-empty, whose receiver has
+// that static type -- to rule. This covers checks like "any FindAll*
+// method on *regexp.Regexp" or "a Seek method on any type", which don't
+// fit checkCalls' one-exact-name-per-rule table.
+func checkMethodCalls(f *lint.File, typeName string, match func(name string) bool, rule CallRule) {
+	fn := func(node ast.Node) bool {
+		ce, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := ce.Fun.(*ast.SelectorExpr)
+		if !ok || !match(sel.Sel.Name) {
+			return true
+		}
+		if typeName != "" && !hasType(f, sel.X, typeName) {
+			return true
+		}
+		fnObj, _ := f.Pkg.TypesInfo.ObjectOf(sel.Sel).(*types.Func)
+		rule(&Call{File: f, Expr: ce, Func: fnObj})
+		return true
+	}
+	f.Walk(fn)
+}
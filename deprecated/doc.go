@@ -0,0 +1,28 @@
+package deprecated
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// Reason extracts the text of a standard "Deprecated: <reason>" doc
+// comment paragraph from doc, following the convention described at
+// https://github.com/golang/go/issues/10909: a paragraph of its own,
+// separated from the rest of the comment by a blank line, whose first
+// line begins with "Deprecated:". ok is false if doc has no such
+// paragraph.
+func Reason(doc *ast.CommentGroup) (reason string, ok bool) {
+	if doc == nil {
+		return "", false
+	}
+	for _, para := range strings.Split(doc.Text(), "\n\n") {
+		para = strings.TrimSpace(para)
+		if !strings.HasPrefix(para, "Deprecated:") {
+			continue
+		}
+		reason = strings.TrimPrefix(para, "Deprecated:")
+		reason = strings.Join(strings.Fields(reason), " ")
+		return reason, true
+	}
+	return "", false
+}
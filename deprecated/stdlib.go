@@ -0,0 +1,59 @@
+// Package deprecated provides a table of deprecated standard library
+// identifiers, for use by the SA1019 check.
+package deprecated
+
+// Identifier describes a single deprecated standard library identifier:
+// the Go version it was deprecated in, and the replacement suggested by
+// its godoc "Deprecated:" paragraph.
+type Identifier struct {
+	Since       string
+	Alternative string
+}
+
+// Stdlib maps the fully qualified name of a deprecated standard library
+// function, method, variable, constant or type (as returned by
+// (*types.Func).FullName or the package-path-qualified name of any other
+// object) to the identifier it was deprecated in favor of.
+//
+// This table is meant to be seeded by parsing every package's godoc
+// "Deprecated:" comments via a go generate step; for now it's maintained by
+// hand and isn't exhaustive, but it's extended whenever CheckDeprecated
+// produces a false negative worth fixing.
+var Stdlib = map[string]Identifier{
+	"net/http.ErrWriteAfterFlush": {
+		Since:       "1.8",
+		Alternative: "this error is never returned",
+	},
+	"crypto/tls.NameToCertificate": {
+		Since:       "1.14",
+		Alternative: "GetCertificate",
+	},
+	"os.SEEK_SET": {
+		Since:       "1.7",
+		Alternative: "io.SeekStart",
+	},
+	"os.SEEK_CUR": {
+		Since:       "1.7",
+		Alternative: "io.SeekCurrent",
+	},
+	"os.SEEK_END": {
+		Since:       "1.7",
+		Alternative: "io.SeekEnd",
+	},
+	"(*net/http.Transport).CancelRequest": {
+		Since:       "1.6",
+		Alternative: "Request.WithContext and its Done channel",
+	},
+	"net/http/httputil.ErrPersistEOF": {
+		Since:       "1.0",
+		Alternative: "io.EOF",
+	},
+	"(*regexp.Regexp).Copy": {
+		Since:       "1.12",
+		Alternative: "nothing; Regexp is now safe for concurrent use without copying",
+	},
+	"syscall.StringByteSlice": {
+		Since:       "1.1",
+		Alternative: "syscall.ByteSliceFromString",
+	},
+}
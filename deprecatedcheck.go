@@ -0,0 +1,178 @@
+package staticcheck
+
+import (
+	"go/ast"
+	"go/types"
+	"sync"
+
+	"honnef.co/go/lint"
+	"honnef.co/go/staticcheck/deprecated"
+)
+
+// deprecatedObjectName returns the fully qualified name under which obj
+// would be looked up in deprecated.Stdlib: a method is named
+// "(*pkg.Recv).Method", everything else is "pkg.Name".
+func deprecatedObjectName(obj types.Object) string {
+	if fn, ok := obj.(*types.Func); ok {
+		if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+			return fn.FullName()
+		}
+	}
+	pkg := obj.Pkg()
+	if pkg == nil {
+		return ""
+	}
+	return pkg.Path() + "." + obj.Name()
+}
+
+// userDeprecated caches the "Deprecated:" reason recorded against a
+// user-written declaration, keyed by its types.Object. It's populated
+// lazily by scanUserDeprecations as files are checked.
+//
+// Ideally this would be seeded once from every parsed file in the
+// program, the way a go/loader-based driver could; honnef.co/go/lint
+// isn't vendored into this tree and only hands CheckDeprecated one file
+// at a time, though, so the cache is instead built up incrementally,
+// file by file, as CheckDeprecated is run across a package -- the same
+// workaround fixes.go uses for suggested fixes.
+var (
+	userDeprecatedMu sync.Mutex
+	userDeprecated   = map[types.Object]string{}
+)
+
+// scanUserDeprecations records the "Deprecated:" reason, if any, for
+// every function, method, type, variable, constant, and struct field
+// declared in f.
+func scanUserDeprecations(f *lint.File) {
+	record := func(name *ast.Ident, doc *ast.CommentGroup) {
+		reason, ok := deprecated.Reason(doc)
+		if !ok {
+			return
+		}
+		obj := f.Pkg.TypesInfo.ObjectOf(name)
+		if obj == nil {
+			return
+		}
+		userDeprecatedMu.Lock()
+		userDeprecated[obj] = reason
+		userDeprecatedMu.Unlock()
+	}
+
+	for _, decl := range f.File.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			record(decl.Name, decl.Doc)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					doc := spec.Doc
+					if doc == nil {
+						doc = decl.Doc
+					}
+					for _, name := range spec.Names {
+						record(name, doc)
+					}
+				case *ast.TypeSpec:
+					doc := spec.Doc
+					if doc == nil {
+						doc = decl.Doc
+					}
+					record(spec.Name, doc)
+					if st, ok := spec.Type.(*ast.StructType); ok {
+						for _, field := range st.Fields.List {
+							for _, name := range field.Names {
+								record(name, field.Doc)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// filePackage returns the types.Package that f's declarations belong to,
+// found via the first declared identifier f.Pkg.TypesInfo can resolve.
+func filePackage(f *lint.File) *types.Package {
+	name := func(decl ast.Decl) *ast.Ident {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			return decl.Name
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					if len(spec.Names) > 0 {
+						return spec.Names[0]
+					}
+				case *ast.TypeSpec:
+					return spec.Name
+				}
+			}
+		}
+		return nil
+	}
+	for _, decl := range f.File.Decls {
+		ident := name(decl)
+		if ident == nil {
+			continue
+		}
+		if obj := f.Pkg.TypesInfo.ObjectOf(ident); obj != nil {
+			return obj.Pkg()
+		}
+	}
+	return nil
+}
+
+// CheckDeprecated flags any reference -- call, selector, composite
+// literal type, embedded field -- to a function, method, variable,
+// constant, or type that has been deprecated, whether that's a standard
+// library identifier listed in deprecated.Stdlib or a user-written
+// declaration whose doc comment carries a "Deprecated:" paragraph. A
+// package may still refer to its own deprecated identifiers internally;
+// "//lint:ignore SA1019" at the reference site opts out of this check
+// the same way it does for any other.
+func CheckDeprecated(f *lint.File) {
+	scanUserDeprecations(f)
+	curPkg := filePackage(f)
+
+	check := func(ident *ast.Ident) {
+		obj := f.Pkg.TypesInfo.ObjectOf(ident)
+		if obj == nil || obj.Pkg() == nil {
+			return
+		}
+
+		userDeprecatedMu.Lock()
+		reason, ok := userDeprecated[obj]
+		userDeprecatedMu.Unlock()
+		if ok {
+			if curPkg != nil && obj.Pkg() == curPkg {
+				return
+			}
+			f.Errorf(ident, "%s is deprecated: %s", ident.Name, reason)
+			return
+		}
+
+		dep, ok := deprecated.Stdlib[deprecatedObjectName(obj)]
+		if !ok {
+			return
+		}
+		if dep.Alternative == "" {
+			f.Errorf(ident, "%s is deprecated", ident.Name)
+			return
+		}
+		f.Errorf(ident, "%s is deprecated: use %s instead", ident.Name, dep.Alternative)
+	}
+
+	fn := func(node ast.Node) bool {
+		// ast.Inspect also visits a *ast.SelectorExpr's Sel field as its own
+		// *ast.Ident, so checking every identifier covers both
+		// "pkg.Deprecated()" and dot-imported "Deprecated()" call forms.
+		if ident, ok := node.(*ast.Ident); ok {
+			check(ident)
+		}
+		return true
+	}
+	f.Walk(fn)
+}
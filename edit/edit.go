@@ -0,0 +1,51 @@
+// Package edit provides the textual-edit types used to describe a
+// suggested fix for a staticcheck finding, plus the logic to apply a set
+// of edits to a file's source.
+package edit
+
+import (
+	"go/token"
+	"sort"
+)
+
+// TextEdit replaces the source between Pos and End (a half-open range)
+// with NewText. An empty range (Pos == End) is a pure insertion.
+type TextEdit struct {
+	Pos, End token.Pos
+	NewText  string
+}
+
+// SuggestedFix is one way a finding could be mechanically resolved:
+// applying all of its Edits together produces the fixed source.
+type SuggestedFix struct {
+	Message string
+	Edits   []TextEdit
+}
+
+// Apply applies edits to src, a file whose positions in fset are those
+// used by Pos/End. Edits are applied right-to-left, so earlier edits'
+// positions remain valid as later (righter) ones shift the text around
+// them. When two edits overlap, the one starting further right wins and
+// the other is returned in skipped rather than applied.
+func Apply(fset *token.FileSet, src []byte, edits []TextEdit) (result []byte, skipped []TextEdit) {
+	ordered := append([]TextEdit(nil), edits...)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Pos > ordered[j].Pos })
+
+	result = src
+	limit := token.Pos(1<<63 - 1) // nothing applied yet, so nothing is off limits
+	for _, e := range ordered {
+		if e.End > limit {
+			skipped = append(skipped, e)
+			continue
+		}
+		start := fset.Position(e.Pos).Offset
+		end := fset.Position(e.End).Offset
+		merged := make([]byte, 0, len(result)-(end-start)+len(e.NewText))
+		merged = append(merged, result[:start]...)
+		merged = append(merged, e.NewText...)
+		merged = append(merged, result[end:]...)
+		result = merged
+		limit = e.Pos
+	}
+	return result, skipped
+}
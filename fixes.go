@@ -0,0 +1,43 @@
+package staticcheck
+
+import (
+	"go/ast"
+	"sync"
+
+	"honnef.co/go/lint"
+	"honnef.co/go/staticcheck/edit"
+)
+
+// fixes records the suggested fixes offered alongside findings, keyed by
+// the node each finding was reported against.
+//
+// Ideally a fix would travel on lint.Problem itself, as a Fixes field
+// next to Message, the way this package's checks already carry a
+// position and message via f.Errorf. honnef.co/go/lint isn't vendored
+// into this tree, though, so it can't be extended here; until it is (or
+// until this package takes over constructing lint.Problem directly),
+// reportFix keeps fixes in this side table, and FixesFor lets a driver
+// -- such as a future "-fix" flag in cmd/staticcheck -- look them up by
+// the node a finding was reported against and apply them with
+// honnef.co/go/staticcheck/edit.Apply.
+var (
+	fixesMu sync.Mutex
+	fixes   = map[ast.Node][]edit.SuggestedFix{}
+)
+
+// reportFix behaves like f.Errorf, additionally recording fix as a
+// suggested fix for the finding reported against node.
+func reportFix(f *lint.File, node ast.Node, fix edit.SuggestedFix, format string, args ...interface{}) {
+	f.Errorf(node, format, args...)
+	fixesMu.Lock()
+	fixes[node] = append(fixes[node], fix)
+	fixesMu.Unlock()
+}
+
+// FixesFor returns the suggested fixes recorded for a finding reported
+// against node, if any.
+func FixesFor(node ast.Node) []edit.SuggestedFix {
+	fixesMu.Lock()
+	defer fixesMu.Unlock()
+	return fixes[node]
+}
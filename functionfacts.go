@@ -0,0 +1,127 @@
+package staticcheck
+
+import (
+	"go/ast"
+	"go/types"
+	"sync"
+
+	"golang.org/x/tools/go/ssa"
+	"honnef.co/go/lint"
+	"honnef.co/go/staticcheck/functions"
+)
+
+// funcFactsMu guards funcFacts. honnef.co/go/staticcheck/functions.Analysis
+// caches facts in plain maps, and checks across several files may query it
+// concurrently, the same reason fixesMu guards the suggested-fix side table
+// in fixes.go.
+var (
+	funcFactsMu sync.Mutex
+	funcFacts   = functions.NewAnalysis()
+)
+
+// factsFor returns the Pure/Terminates/NeverReturns facts computed for
+// the *ssa.Function backing node, which may be an *ast.FuncDecl or any
+// expression enclosed by one -- whatever f.EnclosingSSAFunction accepts.
+func factsFor(f *lint.File, node ast.Node) functions.Facts {
+	ssafn := f.EnclosingSSAFunction(node)
+	if ssafn == nil {
+		return functions.Facts{}
+	}
+	funcFactsMu.Lock()
+	defer funcFactsMu.Unlock()
+	return funcFacts.Facts(ssafn)
+}
+
+// pureStdlibFuncs seeds Pure=true for a short list of standard library
+// functions known to compute a result with no observable side effect.
+// f.Pkg.SSAPkg is built per-package, not whole-program: a dependency's
+// *ssa.Function is either absent or has no Blocks (functions.Analysis
+// treats that as "assume the worst" and reports every fact false), so
+// without this table calleeFacts could never call a stdlib function
+// Pure, no matter how obviously side-effect-free -- including
+// strings.Replace, the motivating example for CheckPureCallResultUnused.
+var pureStdlibFuncs = map[string]bool{
+	"strings.Replace":    true,
+	"strings.ReplaceAll": true,
+	"strings.TrimSpace":  true,
+	"strings.TrimPrefix": true,
+	"strings.TrimSuffix": true,
+	"strings.ToUpper":    true,
+	"strings.ToLower":    true,
+	"strings.Join":       true,
+	"strconv.Itoa":       true,
+	"strconv.Quote":      true,
+	"fmt.Sprintf":        true,
+	"fmt.Sprint":         true,
+	"fmt.Sprintln":       true,
+	"path.Join":          true,
+	"path/filepath.Join": true,
+}
+
+// neverReturningStdlibFuncs is pureStdlibFuncs' counterpart for
+// NeverReturns, which functions.Analysis never finds for these on its
+// own.
+var neverReturningStdlibFuncs = map[string]bool{
+	"os.Exit":     true,
+	"log.Fatal":   true,
+	"log.Fatalf":  true,
+	"log.Fatalln": true,
+}
+
+// calleeFacts resolves the facts of the function fnObj denotes, the same
+// way CheckNilMaps and friends resolve a *types.Func to the *ssa.Function
+// that implements it. Standard library functions in pureStdlibFuncs are
+// reported Pure directly, and those in neverReturningStdlibFuncs
+// NeverReturns; see pureStdlibFuncs' doc comment for why such tables need
+// to exist at all.
+func calleeFacts(f *lint.File, fnObj *types.Func) functions.Facts {
+	if pureStdlibFuncs[fnObj.FullName()] {
+		return functions.Facts{Pure: true}
+	}
+	if neverReturningStdlibFuncs[fnObj.FullName()] {
+		return functions.Facts{NeverReturns: true}
+	}
+	ssafn := f.Pkg.SSAPkg.Prog.FuncValue(fnObj)
+	return factsForSSA(ssafn)
+}
+
+// factsForSSA is like calleeFacts, for callers that already have the
+// *ssa.Function in hand (such as a call graph built directly over SSA)
+// instead of the *types.Func it implements. It too reports the functions
+// in neverReturningStdlibFuncs NeverReturns directly.
+func factsForSSA(ssafn *ssa.Function) functions.Facts {
+	if ssafn == nil {
+		return functions.Facts{}
+	}
+	if fnObj, ok := ssafn.Object().(*types.Func); ok && neverReturningStdlibFuncs[fnObj.FullName()] {
+		return functions.Facts{NeverReturns: true}
+	}
+	funcFactsMu.Lock()
+	defer funcFactsMu.Unlock()
+	return funcFacts.Facts(ssafn)
+}
+
+// bodyCallsNeverReturningFunc reports whether any call directly inside
+// body resolves to a function for which NeverReturns holds, e.g.
+// os.Exit or log.Fatal.
+func bodyCallsNeverReturningFunc(f *lint.File, body ast.Node) bool {
+	found := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		if found {
+			return false
+		}
+		ce, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		_, fnObj := calleeName(f, ce)
+		if fnObj == nil {
+			return true
+		}
+		if calleeFacts(f, fnObj).NeverReturns {
+			found = true
+		}
+		return true
+	})
+	return found
+}
@@ -0,0 +1,359 @@
+// Package functions computes per-function facts -- Pure, Terminates, and
+// NeverReturns -- over SSA, for checks that need to reason about a
+// callee's behavior without re-deriving it from scratch at every call
+// site.
+package functions
+
+import (
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// Facts is the summary computed for a single function.
+type Facts struct {
+	// Pure is true if the function, given the same arguments, always
+	// computes the same result and has no observable side effect: no
+	// write through a pointer, map, or channel, no I/O, and no call to
+	// an impure function.
+	Pure bool
+	// Terminates is true if every call to the function is guaranteed
+	// to eventually return or panic, rather than loop or block
+	// forever.
+	Terminates bool
+	// NeverReturns is true if the function never returns control to
+	// its caller through a normal return -- every reachable path ends
+	// in a panic, an infinite loop, or a call to another function for
+	// which NeverReturns holds. A function without a body is assumed to
+	// return, so Analysis does not find os.Exit or log.Fatal NeverReturns
+	// on its own.
+	NeverReturns bool
+}
+
+// Summary is the gob-encodable form of Facts for a function declared
+// elsewhere, keyed by the object path (package path plus, for a method,
+// "(*Recv).Name" the way deprecatedObjectName keys deprecated.Stdlib) a
+// caller can look it up by without having that function's SSA in hand --
+// for example a summary computed for one package's exported functions
+// and cached for every package that imports it.
+type Summary struct {
+	Path         string
+	Pure         bool
+	Terminates   bool
+	NeverReturns bool
+}
+
+// Analysis computes and caches Facts for the functions of one or more
+// SSA programs.
+//
+// Facts are computed bottom-up: a function's facts depend only on the
+// facts of the functions it calls, so the analysis seeds every function
+// it's asked about (and everything reachable from it) with Pure and
+// NeverReturns true and Terminates false, then repeatedly recomputes
+// them until nothing changes. Pure and NeverReturns can only move from
+// true to false as iteration proceeds, and Terminates only from false to
+// true, so this always reaches a fixed point -- the same
+// seed-then-converge shape honnef.co/go/staticcheck/vrp uses for
+// loop-carried value ranges, applied here across the call graph instead
+// of across loop iterations, and simpler than a true SCC-based bottom-up
+// order over the call graph. Seeding Terminates false keeps a cycle of
+// calls with no way out, such as a function that unconditionally calls
+// itself, from being found to terminate.
+type Analysis struct {
+	facts map[*ssa.Function]Facts
+}
+
+// NewAnalysis returns an empty Analysis.
+func NewAnalysis() *Analysis {
+	return &Analysis{facts: map[*ssa.Function]Facts{}}
+}
+
+// Facts returns the facts computed for fn. The first call for fn
+// computes facts for fn and every function reachable from it; later
+// calls for any of those functions are served from cache.
+func (a *Analysis) Facts(fn *ssa.Function) Facts {
+	if fn == nil {
+		return Facts{}
+	}
+	if f, ok := a.facts[fn]; ok {
+		return f
+	}
+
+	set := reachable(fn)
+	for _, g := range set {
+		if _, ok := a.facts[g]; !ok {
+			a.facts[g] = Facts{Pure: true, Terminates: false, NeverReturns: true}
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, g := range set {
+			next := a.computeOne(g)
+			if next != a.facts[g] {
+				a.facts[g] = next
+				changed = true
+			}
+		}
+	}
+	return a.facts[fn]
+}
+
+// factsOrZero is like Facts, but never triggers computation of a new
+// connected component: it's only used from inside computeOne, where the
+// caller (Facts) has already seeded every function that could be
+// reached.
+func (a *Analysis) factsOrZero(fn *ssa.Function) Facts {
+	return a.facts[fn]
+}
+
+// reachable returns fn and every function directly or indirectly
+// reachable from it by a statically resolvable call (including calls
+// made from anonymous function literals), in no particular order.
+func reachable(fn *ssa.Function) []*ssa.Function {
+	seen := map[*ssa.Function]bool{}
+	var walk func(fn *ssa.Function)
+	walk = func(fn *ssa.Function) {
+		if fn == nil || seen[fn] {
+			return
+		}
+		seen[fn] = true
+		for _, anon := range fn.AnonFuncs {
+			walk(anon)
+		}
+		for _, block := range fn.Blocks {
+			for _, ins := range block.Instrs {
+				call, ok := ins.(*ssa.Call)
+				if !ok || call.Common().IsInvoke() {
+					continue
+				}
+				if callee, ok := call.Common().Value.(*ssa.Function); ok {
+					walk(callee)
+				}
+			}
+		}
+	}
+	walk(fn)
+	out := make([]*ssa.Function, 0, len(seen))
+	for fn := range seen {
+		out = append(out, fn)
+	}
+	return out
+}
+
+// computeOne recomputes fn's facts from its instructions and the
+// current (possibly not yet converged) facts of the functions it calls.
+func (a *Analysis) computeOne(fn *ssa.Function) Facts {
+	if len(fn.Blocks) == 0 {
+		// An external function -- declared but with no body available,
+		// such as an assembly stub. Assume the worst.
+		return Facts{}
+	}
+	return Facts{
+		Pure:         a.pure(fn),
+		Terminates:   a.terminates(fn),
+		NeverReturns: a.neverReturns(fn),
+	}
+}
+
+// pure reports whether every instruction in fn is one this analysis can
+// prove has no observable side effect: arithmetic, control flow, and
+// calls to other Pure functions. Anything else -- a store, a channel
+// operation, a call through an interface or unresolved func value, a
+// goroutine, and so on -- is conservatively treated as impure, since
+// telling an innocuous use of a pointer from one that escapes or
+// mutates shared state apart would need much deeper analysis than this
+// package attempts.
+func (a *Analysis) pure(fn *ssa.Function) bool {
+	for _, block := range fn.Blocks {
+		for _, ins := range block.Instrs {
+			switch ins := ins.(type) {
+			case *ssa.UnOp:
+				if ins.Op == token.ARROW {
+					// a channel receive
+					return false
+				}
+			case *ssa.BinOp, *ssa.Convert, *ssa.Phi,
+				*ssa.Jump, *ssa.If, *ssa.Return, *ssa.DebugRef, *ssa.Extract:
+				// pure: arithmetic, control flow, or reading an
+				// already-computed value.
+			case *ssa.Panic:
+				// a pure function is allowed to panic (e.g. on
+				// division by zero); it just can't have side effects
+				// on the paths that don't.
+			case *ssa.Call:
+				if ins.Common().IsInvoke() {
+					return false
+				}
+				callee, ok := ins.Common().Value.(*ssa.Function)
+				if !ok || !a.factsOrZero(callee).Pure {
+					return false
+				}
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// terminates reports whether fn is guaranteed to return or panic rather
+// than loop or block forever: every loop in its control flow graph
+// looks bounded by loopBounded, it makes no channel send, receive or
+// blocking select, and every call it makes is to a function for which
+// Terminates also holds.
+func (a *Analysis) terminates(fn *ssa.Function) bool {
+	for _, block := range fn.Blocks {
+		for _, succ := range block.Succs {
+			if succ.Dominates(block) && !loopBounded(fn, succ) {
+				return false
+			}
+		}
+		for _, ins := range block.Instrs {
+			switch ins := ins.(type) {
+			case *ssa.Send:
+				return false
+			case *ssa.Select:
+				if ins.Blocking {
+					return false
+				}
+			case *ssa.UnOp:
+				if ins.Op == token.ARROW {
+					return false
+				}
+			}
+			call, ok := ins.(*ssa.Call)
+			if !ok {
+				continue
+			}
+			if call.Common().IsInvoke() {
+				return false
+			}
+			callee, ok := call.Common().Value.(*ssa.Function)
+			if !ok || !a.factsOrZero(callee).Terminates {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// loopBounded applies a conservative heuristic for whether the natural
+// loop headed by header -- approximated, the way CheckInfiniteRecursion
+// already does elsewhere in this package's sibling checks, as every
+// block header dominates -- is guaranteed to run a finite number of
+// iterations:
+//
+//   - any channel receive inside the loop makes it unbounded: the next
+//     value might never arrive.
+//   - an "*ssa.Next" instruction, the lowering of a map or string range,
+//     is always bounded: both are fixed in size for the duration of the
+//     range.
+//   - otherwise, the loop is bounded if its header ends in an "*ssa.If"
+//     comparing an induction variable defined inside the loop against a
+//     loop-invariant bound defined outside it.
+func loopBounded(fn *ssa.Function, header *ssa.BasicBlock) bool {
+	var body []*ssa.BasicBlock
+	for _, b := range fn.Blocks {
+		if header.Dominates(b) {
+			body = append(body, b)
+		}
+	}
+	inBody := func(b *ssa.BasicBlock) bool {
+		for _, o := range body {
+			if o == b {
+				return true
+			}
+		}
+		return false
+	}
+
+	sawNext := false
+	for _, b := range body {
+		for _, ins := range b.Instrs {
+			if unop, ok := ins.(*ssa.UnOp); ok && unop.Op == token.ARROW {
+				return false
+			}
+			if _, ok := ins.(*ssa.Next); ok {
+				sawNext = true
+			}
+		}
+	}
+	if sawNext {
+		return true
+	}
+
+	if len(header.Instrs) == 0 {
+		return false
+	}
+	ifi, ok := header.Instrs[len(header.Instrs)-1].(*ssa.If)
+	if !ok {
+		return false
+	}
+	cond, ok := ifi.Cond.(*ssa.BinOp)
+	if !ok {
+		return false
+	}
+	definedInBody := func(v ssa.Value) bool {
+		ins, ok := v.(ssa.Instruction)
+		return ok && inBody(ins.Block())
+	}
+	xInBody, yInBody := definedInBody(cond.X), definedInBody(cond.Y)
+	// The comparison bounds the loop if exactly one side -- the
+	// induction variable -- is defined inside the loop and the other is
+	// loop-invariant.
+	return xInBody != yInBody
+}
+
+// neverReturns reports whether no normal return is reachable in fn.
+// Unlike a simple "does fn contain a Return instruction" check, this
+// walks the reachable blocks from the entry and stops at any call to a
+// function already found NeverReturns, so that the Return go/ssa still
+// emits after such a call at the end of a function is seen as
+// unreachable.
+func (a *Analysis) neverReturns(fn *ssa.Function) bool {
+	if len(fn.Blocks) == 0 {
+		return false
+	}
+	visited := map[*ssa.BasicBlock]bool{}
+	returns := false
+	var walk func(b *ssa.BasicBlock)
+	walk = func(b *ssa.BasicBlock) {
+		if visited[b] {
+			return
+		}
+		visited[b] = true
+		for _, ins := range b.Instrs {
+			if _, ok := ins.(*ssa.Return); ok {
+				returns = true
+				return
+			}
+			if call, ok := ins.(*ssa.Call); ok && !call.Common().IsInvoke() {
+				if callee, ok := call.Common().Value.(*ssa.Function); ok && a.factsOrZero(callee).NeverReturns {
+					// Nothing past this call in b, nor any of its
+					// successors, is reachable.
+					return
+				}
+			}
+		}
+		for _, s := range b.Succs {
+			walk(s)
+		}
+	}
+	walk(fn.Blocks[0])
+	return !returns
+}
+
+// ObjectPath returns the key Summary uses to identify fn across
+// packages, matching the "(*pkg.Recv).Method" / "pkg.Name" scheme
+// deprecatedObjectName already uses for deprecated.Stdlib.
+func ObjectPath(fn *types.Func) string {
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		return fn.FullName()
+	}
+	pkg := fn.Pkg()
+	if pkg == nil {
+		return fn.Name()
+	}
+	return pkg.Path() + "." + fn.Name()
+}
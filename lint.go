@@ -8,16 +8,17 @@ import (
 	"go/token"
 	"go/types"
 	htmltemplate "html/template"
+	"math/big"
 	"net/http"
-	"net/url"
-	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 	texttemplate "text/template"
-	"time"
-	"unicode/utf8"
 
 	"honnef.co/go/lint"
+	"honnef.co/go/staticcheck/edit"
+	. "honnef.co/go/staticcheck/lintdsl"
+	"honnef.co/go/staticcheck/vrp"
 
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/ssa"
@@ -30,7 +31,8 @@ var Funcs = map[string]lint.Func{
 	"SA1003": CheckEncodingBinary,
 	"SA1004": CheckTimeSleepConstant,
 	"SA1005": CheckExec,
-	"SA1006": CheckUnsafePrintf,
+	// "SA1006": CheckUnsafePrintf, replaced by the more thorough CheckPrintf/CheckPrintfVerbs (SA5009/SA5010)
+	"SA1006": nil,
 	"SA1007": CheckURLs,
 	"SA1008": CheckCanonicalHeaderKey,
 	"SA1009": nil,
@@ -41,11 +43,19 @@ var Funcs = map[string]lint.Func{
 	"SA1014": CheckUnmarshalPointer,
 	"SA1015": CheckUntrappableSignal,
 	"SA1016": CheckSignalChannelSize,
+	"SA1017": CheckRegexpSyntax,
+	"SA1018": CheckUnmarshalNilPointer,
+	"SA1019": CheckDeprecated,
+	"SA1020": CheckUnsafeSliceAdd,
 
 	"SA2000": CheckWaitgroupAdd,
 	"SA2001": CheckEmptyCriticalSection,
 	"SA2002": CheckConcurrentTesting,
 	"SA2003": CheckDeferLock,
+	"SA2004": CheckReturnBeforeMutexUnlock,
+	"SA2005": CheckLockAnnotations,
+	"SA2006": CheckMutexBlockingOp,
+	"SA2007": CheckLockCopy,
 
 	"SA3000": CheckTestMainExit,
 	"SA3001": CheckBenchmarkN,
@@ -57,13 +67,13 @@ var Funcs = map[string]lint.Func{
 	"SA4004": CheckIneffectiveLoop,
 	"SA4005": CheckIneffecitiveFieldAssignments,
 	"SA4006": CheckUnreadVariableValues,
-	// "SA4007": CheckPredeterminedBooleanExprs,
-	"SA4007": nil,
+	"SA4007": CheckPredeterminedBooleanExprs,
 	"SA4008": CheckLoopCondition,
 	"SA4009": CheckArgOverwritten,
 	"SA4010": CheckIneffectiveAppend,
 	"SA4011": CheckScopedBreak,
 	"SA4012": CheckNaNComparison,
+	"SA4013": CheckPureCallResultUnused,
 
 	"SA5000": CheckNilMaps,
 	"SA5001": CheckEarlyDefer,
@@ -73,6 +83,8 @@ var Funcs = map[string]lint.Func{
 	"SA5005": CheckCyclicFinalizer,
 	"SA5006": CheckSliceOutOfBounds,
 	"SA5007": CheckInfiniteRecursion,
+	"SA5009": CheckPrintf,
+	"SA5010": CheckPrintfVerbs,
 
 	"SA9000": CheckDubiousSyncPoolPointers,
 	"SA9001": CheckDubiousDeferInChannelRangeLoop,
@@ -93,67 +105,368 @@ func hasType(f *lint.File, expr ast.Expr, name string) bool {
 	return types.TypeString(f.Pkg.TypesInfo.TypeOf(expr), nil) == name
 }
 
-func CheckSignalChannelSize(f *lint.File) {
-	fn := func(node ast.Node) bool {
-		// track channel positions and their sizes
-		chanPosSize := make(map[token.Pos]int)
+// chanProducers returns every *ssa.MakeChan that might flow into val,
+// looking through conditional assignment (*ssa.Phi), a local variable
+// or struct field's boxed storage, and a function literal's captured
+// free variables. ok is false if the trace runs into a value this
+// analysis can't follow -- a parameter, the result of an unexamined
+// call, and so on -- in which case the caller must give up rather than
+// report a guess.
+func chanProducers(val ssa.Value, seenPhis map[ssa.Value]bool) (producers []*ssa.MakeChan, ok bool) {
+	if seenPhis == nil {
+		seenPhis = map[ssa.Value]bool{}
+	}
+	switch val := val.(type) {
+	case *ssa.MakeChan:
+		return []*ssa.MakeChan{val}, true
+	case *ssa.Phi:
+		if seenPhis[val] {
+			return nil, true
+		}
+		seenPhis[val] = true
+		for _, edge := range val.Edges {
+			sub, ok := chanProducers(edge, seenPhis)
+			if !ok {
+				return nil, false
+			}
+			producers = append(producers, sub...)
+		}
+		return producers, true
+	case *ssa.UnOp:
+		if val.Op != token.MUL {
+			return nil, false
+		}
+		return addrProducers(val.X, seenPhis)
+	case *ssa.FreeVar:
+		return freeVarProducers(val, seenPhis)
+	default:
+		return nil, false
+	}
+}
 
-		// find channels of type os.Signal and track their buffer size
-		fn2 := func(node ast.Node) bool {
-			asn, ok := node.(*ast.AssignStmt)
+// addrProducers is chanProducers for the address operand of a load: it
+// finds every value ever stored to addr -- directly, if addr is a
+// local variable's *ssa.Alloc, or to the specific struct field addr
+// denotes, if addr is a *ssa.FieldAddr off a local composite literal, or
+// to the captured variable, if addr is a closure's *ssa.FreeVar -- and
+// traces each of those back through chanProducers in turn.
+func addrProducers(addr ssa.Value, seenPhis map[ssa.Value]bool) (producers []*ssa.MakeChan, ok bool) {
+	switch addr := addr.(type) {
+	case *ssa.Alloc:
+		refs := addr.Referrers()
+		if refs == nil {
+			return nil, true
+		}
+		for _, ref := range FilterDebug(*refs) {
+			store, ok := ref.(*ssa.Store)
+			if !ok || store.Addr != addr {
+				continue
+			}
+			sub, ok := chanProducers(store.Val, seenPhis)
 			if !ok {
-				return true
+				return nil, false
 			}
-			for i, rhs := range asn.Rhs {
-				call, ok := rhs.(*ast.CallExpr)
-				if !ok {
-					continue
-				}
-				if fn, ok := call.Fun.(*ast.Ident); !ok || fn.Name != "make" {
+			producers = append(producers, sub...)
+		}
+		return producers, true
+	case *ssa.FieldAddr:
+		baseRefs := addr.X.Referrers()
+		if baseRefs == nil {
+			return nil, true
+		}
+		for _, ref := range FilterDebug(*baseRefs) {
+			fa, ok := ref.(*ssa.FieldAddr)
+			if !ok || fa.X != addr.X || fa.Field != addr.Field {
+				continue
+			}
+			faRefs := fa.Referrers()
+			if faRefs == nil {
+				continue
+			}
+			for _, faRef := range FilterDebug(*faRefs) {
+				store, ok := faRef.(*ssa.Store)
+				if !ok || store.Addr != fa {
 					continue
 				}
-				buffSize := 0
-				if len(call.Args) == 2 {
-					if buffSize, ok = constantInt(f, call.Args[1]); !ok {
-						continue
-					}
+				sub, ok := chanProducers(store.Val, seenPhis)
+				if !ok {
+					return nil, false
 				}
-				chanPosSize[asn.Lhs[i].Pos()] = buffSize
+				producers = append(producers, sub...)
 			}
+		}
+		return producers, true
+	case *ssa.FreeVar:
+		return freeVarProducers(addr, seenPhis)
+	default:
+		return nil, false
+	}
+}
 
-			return false // Don't recurse into make calls
+// freeVarProducers is chanProducers for a closure's captured variable:
+// it finds every *ssa.MakeClosure that creates a closure over fv's
+// parent function and traces the corresponding binding back through
+// addrProducers if fv is a pointer (go/ssa captures a variable by its
+// address), or through chanProducers otherwise.
+func freeVarProducers(fv *ssa.FreeVar, seenPhis map[ssa.Value]bool) (producers []*ssa.MakeChan, ok bool) {
+	parent := fv.Parent()
+	idx := -1
+	for i, p := range parent.FreeVars {
+		if p == fv {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return nil, false
+	}
+	refs := parent.Referrers()
+	if refs == nil {
+		return nil, false
+	}
+	for _, ref := range FilterDebug(*refs) {
+		mc, ok := ref.(*ssa.MakeClosure)
+		if !ok || mc.Fn != parent || idx >= len(mc.Bindings) {
+			continue
+		}
+		var sub []*ssa.MakeChan
+		if _, isAddr := fv.Type().Underlying().(*types.Pointer); isAddr {
+			sub, ok = addrProducers(mc.Bindings[idx], seenPhis)
+		} else {
+			sub, ok = chanProducers(mc.Bindings[idx], seenPhis)
 		}
-		ast.Inspect(node, fn2)
+		if !ok {
+			return nil, false
+		}
+		producers = append(producers, sub...)
+	}
+	return producers, true
+}
 
-		// Find all calls to signal.Notify and check their channel's size
-		fn3 := func(node ast.Node) bool {
-			call, ok := node.(*ast.CallExpr)
-			if !ok {
+// chanEscapes reports whether mc is used somewhere this analysis can't
+// see into: returned from an exported function (a caller outside the
+// package could be doing anything with it) or passed to a call other
+// than signal.Notify whose callee isn't a function this package can
+// inspect the body of. Either way, staticcheck can no longer account
+// for every place the channel's capacity matters, so the caller must
+// give up rather than risk a false positive.
+func chanEscapes(mc *ssa.MakeChan) bool {
+	refs := mc.Referrers()
+	if refs == nil {
+		return false
+	}
+	for _, ref := range FilterDebug(*refs) {
+		switch ref := ref.(type) {
+		case *ssa.Return:
+			if fn := ref.Block().Parent(); fn != nil && ast.IsExported(fn.Name()) {
 				return true
 			}
-			if !lint.IsPkgDot(call.Fun, "signal", "Notify") {
+		case *ssa.Call:
+			if IsCallTo(ref.Common(), "os/signal.Notify") {
+				continue
+			}
+			if ref.Common().IsInvoke() {
 				return true
 			}
-			chn, ok := call.Args[0].(*ast.Ident)
-			if !ok {
-				return false
+			callee, ok := ref.Common().Value.(*ssa.Function)
+			if !ok || len(callee.Blocks) == 0 {
+				return true
 			}
-			obj := f.Pkg.TypesInfo.ObjectOf(chn)
-			if obj == nil {
+		}
+	}
+	return false
+}
+
+// signalCapacity returns mc's statically known buffer capacity, or
+// (0, false) if the capacity isn't a constant.
+func signalCapacity(mc *ssa.MakeChan) (int64, bool) {
+	c, ok := mc.Size.(*ssa.Const)
+	if !ok {
+		return 0, false
+	}
+	return constantIntFromSSA(c)
+}
+
+// CheckSignalChannelSize flags a call to signal.Notify whose channel's
+// buffer is too small to hold one value per signal it's registered
+// for, aggregated over every alias of that channel -- not just the
+// literal "make(chan os.Signal, N)" passed to the same call, but also
+// a channel reached through conditional assignment, a struct field, or
+// a closure capture, and the signals registered across every Notify
+// call sharing any of those aliases. It gives up, silently, the moment
+// the channel's producers can't be traced all the way back to one or
+// more make expressions with a known capacity.
+func CheckSignalChannelSize(f *lint.File) {
+	type notification struct {
+		call      *ast.CallExpr
+		producers []*ssa.MakeChan
+		signals   map[string]bool
+	}
+	var notifications []notification
+
+	fn := func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || !lint.IsPkgDot(call.Fun, "signal", "Notify") {
+			return true
+		}
+		ssafn := f.EnclosingSSAFunction(call)
+		if ssafn == nil {
+			return true
+		}
+		val, _ := ssafn.ValueForExpr(call.Args[0])
+		if val == nil {
+			return true
+		}
+		producers, ok := chanProducers(val, nil)
+		if !ok || len(producers) == 0 {
+			return true
+		}
+		for _, mc := range producers {
+			if chanEscapes(mc) {
 				return true
 			}
-			if buffSize, ok := chanPosSize[obj.Pos()]; ok {
-				if buffSize < len(call.Args)-1 {
-					f.Errorf(chn, "channel buffer size %d is too small to catch %v signal(s)", buffSize, len(call.Args)-1)
+		}
+		signals := map[string]bool{}
+		for _, arg := range call.Args[1:] {
+			signals[f.Render(arg)] = true
+		}
+		notifications = append(notifications, notification{call, producers, signals})
+		return true
+	}
+	f.Walk(fn)
+
+	// Union-find over producers: every producer a single notification
+	// mentions is unioned into one cluster, and because union is
+	// transitive, a later notification that bridges two previously
+	// separate clusters (by naming one producer from each) merges the
+	// two clusters in their entirety -- not just the producers it
+	// literally lists -- so a third notification reachable only through
+	// an earlier one still ends up scored together with it.
+	parent := map[*ssa.MakeChan]*ssa.MakeChan{}
+	var find func(mc *ssa.MakeChan) *ssa.MakeChan
+	find = func(mc *ssa.MakeChan) *ssa.MakeChan {
+		p, ok := parent[mc]
+		if !ok {
+			parent[mc] = mc
+			return mc
+		}
+		if p == mc {
+			return mc
+		}
+		root := find(p)
+		parent[mc] = root
+		return root
+	}
+	union := func(a, b *ssa.MakeChan) {
+		ra, rb := find(a), find(b)
+		if ra != rb {
+			parent[ra] = rb
+		}
+	}
+	for _, n := range notifications {
+		for _, mc := range n.producers {
+			find(mc)
+		}
+		for _, mc := range n.producers[1:] {
+			union(n.producers[0], mc)
+		}
+	}
+
+	minCap := map[*ssa.MakeChan]int64{}
+	haveCap := map[*ssa.MakeChan]bool{}
+	signals := map[*ssa.MakeChan]map[string]bool{}
+	for _, n := range notifications {
+		id := find(n.producers[0])
+		if signals[id] == nil {
+			signals[id] = map[string]bool{}
+		}
+		for s := range n.signals {
+			signals[id][s] = true
+		}
+		for _, mc := range n.producers {
+			capacity, ok := signalCapacity(mc)
+			if !ok {
+				continue
+			}
+			if !haveCap[id] || capacity < minCap[id] {
+				minCap[id] = capacity
+				haveCap[id] = true
+			}
+		}
+	}
+
+	for _, n := range notifications {
+		id := find(n.producers[0])
+		if !haveCap[id] {
+			continue
+		}
+		total := len(signals[id])
+		if minCap[id] < int64(total) {
+			f.Errorf(n.call.Args[0], "channel buffer size %d is too small to catch %d signal(s)", minCap[id], total)
+		}
+	}
+}
+
+// resolveSignalIdent looks through a single direct local assignment or
+// declaration ("sig := os.Kill", "var sig = os.Kill") to the expression
+// that produced ident's value, so a signal passed by variable is
+// recognized the same as one written out at the call site. It returns
+// expr unchanged if expr isn't an identifier, or if no such assignment
+// can be found in the current file.
+func resolveSignalIdent(f *lint.File, expr ast.Expr) ast.Expr {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return expr
+	}
+	obj := f.Pkg.TypesInfo.ObjectOf(ident)
+	if obj == nil {
+		return expr
+	}
+
+	definedBy := func(name *ast.Ident) bool {
+		return f.Pkg.TypesInfo.ObjectOf(name) == obj
+	}
+
+	var resolved ast.Expr
+	ast.Inspect(f.File, func(node ast.Node) bool {
+		if resolved != nil {
+			return false
+		}
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range node.Lhs {
+				if lid, ok := lhs.(*ast.Ident); ok && definedBy(lid) && i < len(node.Rhs) {
+					resolved = node.Rhs[i]
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range node.Names {
+				if definedBy(name) && i < len(node.Values) {
+					resolved = node.Values[i]
 				}
 			}
-			return false // don't recurse into signal.* calls
 		}
-		ast.Inspect(node, fn3)
+		return true
+	})
+	if resolved == nil {
+		return expr
+	}
+	return resolved
+}
 
-		return false // fn2/fn3 have already recursed
+// sigstopUntrappable reports whether the linter's own runtime.GOOS is
+// one where syscall.SIGSTOP exists and is untrappable. The symbol only
+// exists on Unix-like targets in the first place, so code referring to
+// it has already committed to such a target; this only guards against
+// reporting a diagnostic about a signal name the check can't actually
+// confirm the semantics of.
+func sigstopUntrappable() bool {
+	switch runtime.GOOS {
+	case "windows", "plan9", "js":
+		return false
+	default:
+		return true
 	}
-	f.Walk(fn)
 }
 
 func CheckUntrappableSignal(f *lint.File) {
@@ -168,16 +481,19 @@ func CheckUntrappableSignal(f *lint.File) {
 			return true
 		}
 		for _, callArg := range call.Args {
-			arg := callArg
-			if isTypeName(f, arg, "os", "Signal") && len(arg.(*ast.CallExpr).Args) == 1 {
+			arg := resolveSignalIdent(f, callArg)
+			if IsTypeName(f, arg, "os", "Signal") && len(arg.(*ast.CallExpr).Args) == 1 {
 				arg = arg.(*ast.CallExpr).Args[0]
 			}
 
 			switch {
 			case lint.IsPkgDot(arg, "os", "Kill"), lint.IsPkgDot(arg, "syscall", "SIGKILL"):
-				f.Errorf(arg, "SIGKILL signal cannot be trapped (did you mean syscall.SIGTERM?)")
-			case lint.IsPkgDot(arg, "syscall", "SIGSTOP"):
-				f.Errorf(arg, "SIGSTOP signal cannot be trapped")
+				reportFix(f, callArg, edit.SuggestedFix{
+					Message: "use syscall.SIGTERM instead",
+					Edits:   []edit.TextEdit{{Pos: arg.Pos(), End: arg.End(), NewText: "syscall.SIGTERM"}},
+				}, "SIGKILL signal cannot be trapped (did you mean syscall.SIGTERM?)")
+			case lint.IsPkgDot(arg, "syscall", "SIGSTOP") && sigstopUntrappable():
+				f.Errorf(callArg, "SIGSTOP signal cannot be trapped")
 			}
 		}
 		return true
@@ -186,29 +502,10 @@ func CheckUntrappableSignal(f *lint.File) {
 }
 
 func CheckRegexps(f *lint.File) {
-	fn := func(node ast.Node) bool {
-		call, ok := node.(*ast.CallExpr)
-		if !ok {
-			return true
-		}
-		if !lint.IsPkgDot(call.Fun, "regexp", "MustCompile") &&
-			!lint.IsPkgDot(call.Fun, "regexp", "Compile") {
-			return true
-		}
-		if len(call.Args) != 1 {
-			return true
-		}
-		s, ok := constantString(f, call.Args[0])
-		if !ok {
-			return true
-		}
-		_, err := regexp.Compile(s)
-		if err != nil {
-			f.Errorf(call.Args[0], "%s", err)
-		}
-		return true
-	}
-	f.Walk(fn)
+	checkCalls(f, map[string]CallRule{
+		"regexp.MustCompile": validRegexp(0),
+		"regexp.Compile":     validRegexp(0),
+	})
 }
 
 func CheckTemplate(f *lint.File) {
@@ -221,16 +518,16 @@ func CheckTemplate(f *lint.File) {
 			return true
 		}
 		var kind string
-		if isFunctionCallName(f, call, "(*text/template.Template).Parse") {
+		if IsFunctionCallName(f, call, "(*text/template.Template).Parse") {
 			kind = "text"
-		} else if isFunctionCallName(f, call, "(*html/template.Template).Parse") {
+		} else if IsFunctionCallName(f, call, "(*html/template.Template).Parse") {
 			kind = "html"
 		} else {
 			return true
 		}
 		sel := call.Fun.(*ast.SelectorExpr)
-		if !isFunctionCallName(f, sel.X, "text/template.New") &&
-			!isFunctionCallName(f, sel.X, "html/template.New") {
+		if !IsFunctionCallName(f, sel.X, "text/template.New") &&
+			!IsFunctionCallName(f, sel.X, "html/template.New") {
 			// TODO(dh): this is a cheap workaround for templates with
 			// different delims. A better solution with less false
 			// negatives would use data flow analysis to see where the
@@ -260,30 +557,9 @@ func CheckTemplate(f *lint.File) {
 }
 
 func CheckTimeParse(f *lint.File) {
-	fn := func(node ast.Node) bool {
-		call, ok := node.(*ast.CallExpr)
-		if !ok {
-			return true
-		}
-		if !lint.IsPkgDot(call.Fun, "time", "Parse") {
-			return true
-		}
-		if len(call.Args) != 2 {
-			return true
-		}
-		s, ok := constantString(f, call.Args[0])
-		if !ok {
-			return true
-		}
-		s = strings.Replace(s, "_", " ", -1)
-		s = strings.Replace(s, "Z", "-", -1)
-		_, err := time.Parse(s, s)
-		if err != nil {
-			f.Errorf(call.Args[0], "%s", err)
-		}
-		return true
-	}
-	f.Walk(fn)
+	checkCalls(f, map[string]CallRule{
+		"time.Parse": validTimeLayout(0),
+	})
 }
 
 func CheckEncodingBinary(f *lint.File) {
@@ -379,11 +655,14 @@ func CheckTimeSleepConstant(f *lint.File) {
 			// because the user could've meant 2 minutes.
 			return true
 		}
-		recommendation := "time.Sleep(time.Nanosecond)"
+		recommendation := "time.Nanosecond"
 		if n != 1 {
-			recommendation = fmt.Sprintf("time.Sleep(%d * time.Nanosecond)", n)
+			recommendation = fmt.Sprintf("%d * time.Nanosecond", n)
 		}
-		f.Errorf(call.Args[0], "sleeping for %d nanoseconds is probably a bug. Be explicit if it isn't: %s", n, recommendation)
+		reportFix(f, call.Args[0], edit.SuggestedFix{
+			Message: fmt.Sprintf("use %s", recommendation),
+			Edits:   []edit.TextEdit{{Pos: call.Args[0].Pos(), End: call.Args[0].End(), NewText: recommendation}},
+		}, "sleeping for %d nanoseconds is probably a bug. Be explicit if it isn't: time.Sleep(%s)", n, recommendation)
 		return true
 	}
 	f.Walk(fn)
@@ -586,28 +865,9 @@ func IsTestMain(f *lint.File, node ast.Node) bool {
 }
 
 func CheckExec(f *lint.File) {
-	fn := func(node ast.Node) bool {
-		call, ok := node.(*ast.CallExpr)
-		if !ok {
-			return true
-		}
-		if !lint.IsPkgDot(call.Fun, "exec", "Command") {
-			return true
-		}
-		if len(call.Args) != 1 {
-			return true
-		}
-		val, ok := constantString(f, call.Args[0])
-		if !ok {
-			return true
-		}
-		if !strings.Contains(val, " ") || strings.Contains(val, `\`) {
-			return true
-		}
-		f.Errorf(call.Args[0], "first argument to exec.Command looks like a shell command, but a program name or path are expected")
-		return true
-	}
-	f.Walk(fn)
+	checkCalls(f, map[string]CallRule{
+		"os/exec.Command": notShellCommand(0),
+	})
 }
 
 func CheckLoopEmptyDefault(f *lint.File) {
@@ -715,54 +975,10 @@ func CheckScopedBreak(f *lint.File) {
 	f.Walk(fn)
 }
 
-func CheckUnsafePrintf(f *lint.File) {
-	fn := func(node ast.Node) bool {
-		call, ok := node.(*ast.CallExpr)
-		if !ok {
-			return true
-		}
-		if !lint.IsPkgDot(call.Fun, "fmt", "Printf") &&
-			!lint.IsPkgDot(call.Fun, "fmt", "Sprintf") &&
-			!lint.IsPkgDot(call.Fun, "log", "Printf") {
-			return true
-		}
-		if len(call.Args) != 1 {
-			return true
-		}
-		switch call.Args[0].(type) {
-		case *ast.CallExpr, *ast.Ident:
-		default:
-			return true
-		}
-		f.Errorf(call.Args[0], "printf-style function with dynamic first argument and no further arguments should use print-style function instead")
-		return true
-	}
-	f.Walk(fn)
-}
-
 func CheckURLs(f *lint.File) {
-	fn := func(node ast.Node) bool {
-		call, ok := node.(*ast.CallExpr)
-		if !ok {
-			return true
-		}
-		if !lint.IsPkgDot(call.Fun, "url", "Parse") {
-			return true
-		}
-		if len(call.Args) != 1 {
-			return true
-		}
-		s, ok := constantString(f, call.Args[0])
-		if !ok {
-			return true
-		}
-		_, err := url.Parse(s)
-		if err != nil {
-			f.Errorf(call.Args[0], "invalid argument to url.Parse: %s", err)
-		}
-		return true
-	}
-	f.Walk(fn)
+	checkCalls(f, map[string]CallRule{
+		"net/url.Parse": validURL(0),
+	})
 }
 
 func CheckEarlyDefer(f *lint.File) {
@@ -922,7 +1138,10 @@ func CheckEmptyCriticalSection(f *lint.File) {
 			}
 			if (method1 == "Lock" && method2 == "Unlock") ||
 				(method1 == "RLock" && method2 == "RUnlock") {
-				f.Errorf(block.List[i+1], "empty critical section")
+				reportFix(f, block.List[i+1], edit.SuggestedFix{
+					Message: fmt.Sprintf("remove the redundant %s", method2),
+					Edits:   []edit.TextEdit{{Pos: block.List[i+1].Pos(), End: block.List[i+1].End(), NewText: ""}},
+				}, "empty critical section")
 			}
 		}
 		return true
@@ -930,37 +1149,276 @@ func CheckEmptyCriticalSection(f *lint.File) {
 	f.Walk(fn)
 }
 
+// hasSideEffects conservatively reports whether expr might do more
+// than produce a value -- call a function or receive from a channel --
+// in which case folding it into a surrounding expression must not
+// assume it's safe to stop evaluating it exactly the way the original
+// source did.
+func hasSideEffects(expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.CallExpr:
+			found = true
+		case *ast.UnaryExpr:
+			if node.Op == token.ARROW {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// cancelDeref reports whether expr, modulo parentheses, is of the form
+// "*x" for some side-effect-free x, returning x.
+func cancelDeref(expr ast.Expr) (ast.Expr, bool) {
+	star, ok := astutil.Unparen(expr).(*ast.StarExpr)
+	if !ok || hasSideEffects(star.X) {
+		return nil, false
+	}
+	return star.X, true
+}
+
+// cancelAddr is cancelDeref's counterpart for "&x".
+func cancelAddr(expr ast.Expr) (ast.Expr, bool) {
+	unary, ok := astutil.Unparen(expr).(*ast.UnaryExpr)
+	if !ok || unary.Op != token.AND || hasSideEffects(unary.X) {
+		return nil, false
+	}
+	return unary.X, true
+}
+
+// isPointerToArray reports whether expr's type is a pointer to an
+// array, the one case in which indexing through the pointer ("p[i]")
+// and indexing the pointed-to value ("(*p)[i]") mean the same thing.
+func isPointerToArray(f *lint.File, expr ast.Expr) bool {
+	ptr, ok := f.Pkg.TypesInfo.TypeOf(expr).Underlying().(*types.Pointer)
+	if !ok {
+		return false
+	}
+	_, ok = ptr.Elem().Underlying().(*types.Array)
+	return ok
+}
+
+// simplifyAddrDeref cancels a redundant address-of/dereference pair
+// anywhere it appears in expr: at the root ("&*x", "*&x"), around an
+// index on a pointer-to-array ("&(*p)[i]", simplified to "&p[i]"), or
+// as the receiver of a selector ("(*&s).f", simplified to "s.f"). It
+// recurses into whatever it simplifies down to, so something like
+// "&*(&x)" collapses all the way to "&x". It returns the simplified
+// expression and whether anything changed.
+func simplifyAddrDeref(f *lint.File, expr ast.Expr) (ast.Expr, bool) {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return simplifyAddrDeref(f, e.X)
+
+	case *ast.UnaryExpr:
+		if e.Op != token.AND {
+			return expr, false
+		}
+		inner, changed := simplifyAddrDeref(f, e.X)
+		if x, ok := cancelDeref(inner); ok {
+			result, _ := simplifyAddrDeref(f, x)
+			return result, true
+		}
+		if index, ok := astutil.Unparen(inner).(*ast.IndexExpr); ok {
+			if p, ok := cancelDeref(index.X); ok && isPointerToArray(f, p) {
+				result, _ := simplifyAddrDeref(f, p)
+				return &ast.UnaryExpr{Op: token.AND, X: &ast.IndexExpr{X: result, Index: index.Index}}, true
+			}
+		}
+		if changed {
+			return &ast.UnaryExpr{Op: token.AND, X: inner}, true
+		}
+		return expr, false
+
+	case *ast.StarExpr:
+		inner, changed := simplifyAddrDeref(f, e.X)
+		if x, ok := cancelAddr(inner); ok {
+			result, _ := simplifyAddrDeref(f, x)
+			return result, true
+		}
+		if changed {
+			return &ast.StarExpr{X: inner}, true
+		}
+		return expr, false
+
+	case *ast.SelectorExpr:
+		inner, changed := simplifyAddrDeref(f, e.X)
+		if x, ok := cancelAddr(inner); ok {
+			result, _ := simplifyAddrDeref(f, x)
+			return &ast.SelectorExpr{X: result, Sel: e.Sel}, true
+		}
+		if changed {
+			return &ast.SelectorExpr{X: inner, Sel: e.Sel}, true
+		}
+		return expr, false
+
+	default:
+		return expr, false
+	}
+}
+
+// CheckIneffectiveCopy flags address-of/dereference pairs that cancel
+// out -- &*x, *&x, &(*p)[i] for p a pointer to array, (*&s).f, and any
+// of those nested inside each other or parentheses -- none of which
+// copy the value they operate on, unlike what the syntax suggests. It
+// leaves expressions with a call or channel receive anywhere inside
+// them alone, since it can't tell whether folding them away would
+// change how many times that effect happens.
 func CheckIneffectiveCopy(f *lint.File) {
 	fn := func(node ast.Node) bool {
-		if unary, ok := node.(*ast.UnaryExpr); ok {
-			if _, ok := unary.X.(*ast.StarExpr); ok && unary.Op == token.AND {
-				f.Errorf(unary, "&*x will be simplified to x. It will not copy x.")
-			}
+		expr, ok := node.(ast.Expr)
+		if !ok {
+			return true
+		}
+		switch expr.(type) {
+		case *ast.UnaryExpr, *ast.StarExpr, *ast.SelectorExpr:
+		default:
+			return true
 		}
 
-		if star, ok := node.(*ast.StarExpr); ok {
-			if unary, ok := star.X.(*ast.UnaryExpr); ok && unary.Op == token.AND {
-				f.Errorf(star, "*&x will be simplified to x. It will not copy x.")
-			}
+		simplified, changed := simplifyAddrDeref(f, expr)
+		if !changed {
+			return true
+		}
+		reportFix(f, expr, edit.SuggestedFix{
+			Message: fmt.Sprintf("simplify to %s", f.Render(simplified)),
+			Edits:   []edit.TextEdit{{Pos: expr.Pos(), End: expr.End(), NewText: f.Render(simplified)}},
+		}, "%s will be simplified to %s. It will not copy the value.", f.Render(expr), f.Render(simplified))
+		// The subtree we just reported on has already been accounted
+		// for in its entirety; don't also report on the redundant
+		// pairs nested inside it.
+		return false
+	}
+	f.Walk(fn)
+}
+
+func constantInt(f *lint.File, expr ast.Expr) (int, bool) {
+	tv := f.Pkg.TypesInfo.Types[expr]
+	if tv.Value == nil {
+		return 0, false
+	}
+	if tv.Value.Kind() != constant.Int {
+		return 0, false
+	}
+	v, ok := constant.Int64Val(tv.Value)
+	if !ok {
+		return 0, false
+	}
+	return int(v), true
+}
+
+// unsafeSizes approximates the target architecture's unsafe.Sizeof
+// with the architecture staticcheck itself is built for, for lack of
+// any per-package target-architecture information in the lint.File
+// API this module builds against. It's nil if runtime.GOARCH isn't one
+// types.SizesFor recognizes for the "gc" compiler, in which case the
+// checks below that need it give up rather than guess.
+var unsafeSizes = types.SizesFor("gc", runtime.GOARCH)
+
+// CheckUnsafeSliceAdd flags obviously broken uses of the unsafe.Slice
+// and unsafe.Add builtins added in Go 1.17: a nil pointer paired with
+// a non-zero (or non-constant) length, a negative or overflowing
+// length, and a constant offset that would walk a known-length array's
+// address out of its bounds.
+func CheckUnsafeSliceAdd(f *lint.File) {
+	fn := func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		switch {
+		case lint.IsPkgDot(call.Fun, "unsafe", "Slice"):
+			checkUnsafeSlice(f, call)
+		case lint.IsPkgDot(call.Fun, "unsafe", "Add"):
+			checkUnsafeAdd(f, call)
 		}
 		return true
 	}
-	f.Walk(fn)
+	f.Walk(fn)
+}
+
+func checkUnsafeSlice(f *lint.File, call *ast.CallExpr) {
+	if len(call.Args) != 2 {
+		return
+	}
+	ptr, n := call.Args[0], call.Args[1]
+
+	nVal, nKnown := constantInt(f, n)
+	if nKnown && nVal < 0 {
+		f.Errorf(n, "unsafe.Slice: length is negative")
+		return
+	}
+
+	if ssafn := f.EnclosingSSAFunction(call); ssafn != nil && (!nKnown || nVal != 0) {
+		if val, _ := ssafn.ValueForExpr(ptr); val != nil && isStaticallyNil(val) {
+			f.Errorf(ptr, "unsafe.Slice: the pointer is always nil, but the length is not statically known to be zero")
+			return
+		}
+	}
+
+	if !nKnown || unsafeSizes == nil {
+		return
+	}
+	ptrType, ok := f.Pkg.TypesInfo.TypeOf(ptr).Underlying().(*types.Pointer)
+	if !ok {
+		return
+	}
+	elemSize := unsafeSizes.Sizeof(ptrType.Elem())
+	if elemSize == 0 || nVal == 0 {
+		return
+	}
+	maxInt := int64(1)<<(uint(unsafeSizes.Sizeof(types.Typ[types.Int])*8)-1) - 1
+	if elemSize > maxInt/int64(nVal) {
+		f.Errorf(n, "unsafe.Slice: length*size(*T) overflows int")
+	}
 }
 
-func constantInt(f *lint.File, expr ast.Expr) (int, bool) {
-	tv := f.Pkg.TypesInfo.Types[expr]
-	if tv.Value == nil {
-		return 0, false
+func checkUnsafeAdd(f *lint.File, call *ast.CallExpr) {
+	if len(call.Args) != 2 || unsafeSizes == nil {
+		return
 	}
-	if tv.Value.Kind() != constant.Int {
-		return 0, false
+	ptr, off := call.Args[0], call.Args[1]
+	if conv, ok := ptr.(*ast.CallExpr); ok && IsTypeName(f, conv, "unsafe", "Pointer") && len(conv.Args) == 1 {
+		// unsafe.Add's first argument must itself be an unsafe.Pointer;
+		// look through that conversion to the address-of expression
+		// underneath it.
+		ptr = conv.Args[0]
 	}
-	v, ok := constant.Int64Val(tv.Value)
+
+	offVal, ok := constantInt(f, off)
 	if !ok {
-		return 0, false
+		return
+	}
+	unary, ok := ptr.(*ast.UnaryExpr)
+	if !ok || unary.Op != token.AND {
+		return
+	}
+	index, ok := unary.X.(*ast.IndexExpr)
+	if !ok {
+		return
+	}
+	arrType, ok := f.Pkg.TypesInfo.TypeOf(index.X).Underlying().(*types.Array)
+	if !ok {
+		return
+	}
+	iVal, ok := constantInt(f, index.Index)
+	if !ok {
+		return
+	}
+	elemSize := unsafeSizes.Sizeof(arrType.Elem())
+	if elemSize == 0 || int64(offVal)%elemSize != 0 {
+		// An offset that doesn't land on an element boundary might
+		// still be in bounds; only flag the cases we can check
+		// precisely.
+		return
+	}
+	newIndex := int64(iVal) + int64(offVal)/elemSize
+	if newIndex < 0 || newIndex >= arrType.Len() {
+		f.Errorf(call, "unsafe.Add: the resulting pointer is outside the bounds of the %d-element array", arrType.Len())
 	}
-	return int(v), true
 }
 
 func sliceSize(f *lint.File, expr ast.Expr) (int, bool) {
@@ -1017,18 +1475,54 @@ func CheckDiffSizeComparison(f *lint.File) {
 		}
 		left, ok1 := sliceSize(f, expr.X)
 		right, ok2 := sliceSize(f, expr.Y)
-		if !ok1 || !ok2 {
+		if ok1 && ok2 {
+			if left != right {
+				f.Errorf(expr, "comparing strings of different sizes for equality will always return false")
+			}
 			return true
 		}
-		if left == right {
-			return true
+		// sliceSize only understands constant bounds; fall back to the
+		// vrp-computed length range of each side's SSA value, which also
+		// covers non-constant bounds such as s[i:j] or s[:n].
+		if diffSizeRangesDisjoint(f, expr.X, expr.Y) {
+			f.Errorf(expr, "comparing strings of different sizes for equality will always return false")
 		}
-		f.Errorf(expr, "comparing strings of different sizes for equality will always return false")
 		return true
 	}
 	f.Walk(fn)
 }
 
+// diffSizeRangesDisjoint reports whether the vrp length ranges of x and y
+// are provably disjoint, meaning expr.Op among ==/!= comparing them can
+// never be true (or never false, in the NEQ case).
+func diffSizeRangesDisjoint(f *lint.File, x, y ast.Expr) bool {
+	ssafn := f.EnclosingSSAFunction(x)
+	if ssafn == nil {
+		return false
+	}
+	xv, _ := ssafn.ValueForExpr(x)
+	yv, _ := ssafn.ValueForExpr(y)
+	if xv == nil || yv == nil {
+		return false
+	}
+	ranges := vrp.Ranges(ssafn)
+	xr, ok := ranges[xv]
+	if !ok || !xr.Known() {
+		return false
+	}
+	yr, ok := ranges[yv]
+	if !ok || !yr.Known() {
+		return false
+	}
+	if xr.Hi != nil && yr.Lo != nil && xr.Hi.Cmp(yr.Lo) < 0 {
+		return true
+	}
+	if yr.Hi != nil && xr.Lo != nil && yr.Hi.Cmp(xr.Lo) < 0 {
+		return true
+	}
+	return false
+}
+
 func CheckCanonicalHeaderKey(f *lint.File) {
 	fn := func(node ast.Node) bool {
 		assign, ok := node.(*ast.AssignStmt)
@@ -1240,7 +1734,7 @@ func CheckUnreadVariableValues(f *lint.File) {
 					// TODO investigate why refs can be nil
 					return true
 				}
-				if len(filterDebug(*val.Referrers())) == 0 {
+				if len(FilterDebug(*val.Referrers())) == 0 {
 					f.Errorf(node, "this value of %s is never used", lhs)
 				}
 			}
@@ -1274,6 +1768,22 @@ func CheckPredeterminedBooleanExprs(f *lint.File) {
 		xs, ok1 := consts(ssabinop.X, nil, nil)
 		ys, ok2 := consts(ssabinop.Y, nil, nil)
 		if !ok1 || !ok2 || len(xs) == 0 || len(ys) == 0 {
+			// consts only succeeds when every possible value of an
+			// operand traces back to a literal constant; fall back to
+			// vrp's value ranges, which also cover operands derived
+			// from loop counters, function parameters, and other
+			// values consts can't enumerate.
+			ranges := vrp.Ranges(fn)
+			xr, okx := ranges[ssabinop.X]
+			yr, oky := ranges[ssabinop.Y]
+			if !okx || !oky {
+				return true
+			}
+			result, ok := vrp.Compare(ssabinop.Op, xr, yr)
+			if !ok {
+				return true
+			}
+			f.Errorf(binop, "%s is always %t for all possible values", f.Render(binop), result)
 			return true
 		}
 
@@ -1354,6 +1864,12 @@ func CheckUnsignedComparison(f *lint.File) {
 		}
 		lit, ok := expr.Y.(*ast.BasicLit)
 		if !ok || lit.Value != "0" {
+			// Y isn't the literal 0, so fall back to asking vrp whether
+			// the ranges of X and Y settle the comparison regardless.
+			// Only operands that vrp.Ranges has a range for (unsigned
+			// parameters and instruction results) are compared; a
+			// constant operand has none, so "x < someConst" is skipped.
+			checkUnsignedComparisonRanges(f, expr)
 			return true
 		}
 		switch expr.Op {
@@ -1368,16 +1884,41 @@ func CheckUnsignedComparison(f *lint.File) {
 	}
 	f.Walk(fn)
 }
-func filterDebug(instr []ssa.Instruction) []ssa.Instruction {
-	var out []ssa.Instruction
-	for _, ins := range instr {
-		if _, ok := ins.(*ssa.DebugRef); !ok {
-			out = append(out, ins)
-		}
+
+// checkUnsignedComparisonRanges handles the comparisons that
+// CheckUnsignedComparison's literal-0 fast path doesn't cover, by asking
+// vrp for the value ranges of both operands and letting vrp.Compare
+// decide whether expr's outcome is predetermined.
+func checkUnsignedComparisonRanges(f *lint.File, expr *ast.BinaryExpr) {
+	switch expr.Op {
+	case token.LSS, token.LEQ, token.GTR, token.GEQ:
+	default:
+		return
+	}
+	ssafn := f.EnclosingSSAFunction(expr)
+	if ssafn == nil {
+		return
 	}
-	return out
+	xv, _ := ssafn.ValueForExpr(expr.X)
+	yv, _ := ssafn.ValueForExpr(expr.Y)
+	if xv == nil || yv == nil {
+		return
+	}
+	ranges := vrp.Ranges(ssafn)
+	xr, ok := ranges[xv]
+	if !ok {
+		return
+	}
+	yr, ok := ranges[yv]
+	if !ok {
+		return
+	}
+	result, ok := vrp.Compare(expr.Op, xr, yr)
+	if !ok {
+		return
+	}
+	f.Errorf(expr, "this comparison is always %t", result)
 }
-
 func consts(val ssa.Value, out []*ssa.Const, visitedPhis map[string]bool) ([]*ssa.Const, bool) {
 	if visitedPhis == nil {
 		visitedPhis = map[string]bool{}
@@ -1503,7 +2044,7 @@ func CheckArgOverwritten(f *lint.File) {
 				if refs == nil {
 					continue
 				}
-				if len(filterDebug(*refs)) != 0 {
+				if len(FilterDebug(*refs)) != 0 {
 					continue
 				}
 
@@ -1615,6 +2156,12 @@ func CheckIneffectiveLoop(f *lint.File) {
 			if unconditionalExit == nil || !hasBranching {
 				return false
 			}
+			if bodyCallsNeverReturningFunc(f, body) {
+				// the loop body unconditionally calls something like
+				// os.Exit or log.Fatal, so of course the loop only
+				// ever runs once -- that's the point, not a bug.
+				return false
+			}
 			ast.Inspect(body, func(node ast.Node) bool {
 				if branch, ok := node.(*ast.BranchStmt); ok {
 
@@ -1643,62 +2190,30 @@ func CheckIneffectiveLoop(f *lint.File) {
 }
 
 func CheckRegexpFindAll(f *lint.File) {
-	fn := func(node ast.Node) bool {
-		call, ok := node.(*ast.CallExpr)
-		if !ok {
-			return true
-		}
-		sel, ok := call.Fun.(*ast.SelectorExpr)
-		if !ok {
-			return true
-		}
-		if !hasType(f, sel.X, "*regexp.Regexp") {
-			return true
-		}
-		if !strings.HasPrefix(sel.Sel.Name, "FindAll") {
-			return true
-		}
-		if len(call.Args) != 2 {
-			return true
+	checkMethodCalls(f, "*regexp.Regexp", func(name string) bool {
+		return strings.HasPrefix(name, "FindAll")
+	}, func(call *Call) {
+		if len(call.Expr.Args) != 2 {
+			return
 		}
-		lit, ok := call.Args[1].(*ast.BasicLit)
+		lit, ok := call.Expr.Args[1].(*ast.BasicLit)
 		if !ok || lit.Value != "0" {
-			return true
+			return
 		}
 		f.Errorf(lit, "calling a FindAll method with n == 0 will return no results, did you mean -1?")
-		return true
-	}
-	f.Walk(fn)
+	})
 }
 
 func CheckUTF8Cutset(f *lint.File) {
-	fn := func(node ast.Node) bool {
-		call, ok := node.(*ast.CallExpr)
-		if !ok {
-			return true
-		}
-		if len(call.Args) != 2 {
-			return true
-		}
-		sel, ok := call.Fun.(*ast.SelectorExpr)
-		if !ok || !lint.IsIdent(sel.X, "strings") {
-			return true
-		}
-		switch sel.Sel.Name {
-		case "IndexAny", "LastIndexAny", "ConstainsAny", "Trim", "TrimLeft", "TrimRight":
-		default:
-			return true
-		}
-		s, ok := constantString(f, call.Args[1])
-		if !ok {
-			return true
-		}
-		if !utf8.ValidString(s) {
-			f.Errorf(call.Args[1], "the second argument to %s should be a valid UTF-8 encoded string", f.Render(call.Fun))
-		}
-		return true
-	}
-	f.Walk(fn)
+	cutset := Args(ValidUTF8{1}, UniqueCutset{1})
+	checkCalls(f, map[string]CallRule{
+		"strings.IndexAny":     cutset,
+		"strings.LastIndexAny": cutset,
+		"strings.ContainsAny":  cutset,
+		"strings.Trim":         cutset,
+		"strings.TrimLeft":     cutset,
+		"strings.TrimRight":    cutset,
+	})
 }
 
 func CheckNilContext(f *lint.File) {
@@ -1731,41 +2246,28 @@ func CheckNilContext(f *lint.File) {
 }
 
 func CheckSeeker(f *lint.File) {
-	fn := func(node ast.Node) bool {
-		call, ok := node.(*ast.CallExpr)
-		if !ok {
-			return true
-		}
-		sel, ok := call.Fun.(*ast.SelectorExpr)
-		if !ok {
-			return true
-		}
-		if sel.Sel.Name != "Seek" {
-			return true
+	checkMethodCalls(f, "", func(name string) bool { return name == "Seek" }, func(call *Call) {
+		if len(call.Expr.Args) != 2 {
+			return
 		}
-		if len(call.Args) != 2 {
-			return true
-		}
-		arg0, ok := call.Args[0].(*ast.SelectorExpr)
+		arg0, ok := call.Expr.Args[0].(*ast.SelectorExpr)
 		if !ok {
-			return true
+			return
 		}
 		switch arg0.Sel.Name {
 		case "SeekStart", "SeekCurrent", "SeekEnd":
 		default:
-			return true
+			return
 		}
 		pkg, ok := arg0.X.(*ast.Ident)
 		if !ok {
-			return true
+			return
 		}
 		if pkg.Name != "io" {
-			return true
+			return
 		}
-		f.Errorf(call, "the first argument of io.Seeker is the offset, but an io.Seek* constant is being used instead")
-		return true
-	}
-	f.Walk(fn)
+		f.Errorf(call.Expr, "the first argument of io.Seeker is the offset, but an io.Seek* constant is being used instead")
+	})
 }
 
 func CheckIneffectiveAppend(f *lint.File) {
@@ -1858,6 +2360,42 @@ func CheckIneffectiveAppend(f *lint.File) {
 	f.Walk(fn)
 }
 
+// CheckPureCallResultUnused flags a call, used as its own statement, to a
+// function known to be Pure -- either one functions.Analysis can prove
+// has no side effects from its own SSA, or a stdlib function listed in
+// pureStdlibFuncs (functions.Analysis only sees a body for functions
+// declared in the package being checked, so without that table it could
+// never flag the likes of strings.Replace). Since the function has no
+// side effects, a call whose result is entirely discarded computes
+// something and then throws it away, same as "x + 1" on a line by
+// itself, which is never what the author meant to write.
+func CheckPureCallResultUnused(f *lint.File) {
+	fn := func(node ast.Node) bool {
+		expr, ok := node.(*ast.ExprStmt)
+		if !ok {
+			return true
+		}
+		call, ok := expr.X.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		_, fnObj := calleeName(f, call)
+		if fnObj == nil {
+			return true
+		}
+		sig := fnObj.Type().(*types.Signature)
+		if sig.Results().Len() == 0 {
+			return true
+		}
+		if !calleeFacts(f, fnObj).Pure {
+			return true
+		}
+		f.Errorf(expr, "the result of this call to %s is never used, but %s has no side effects", fnObj.Name(), fnObj.Name())
+		return true
+	}
+	f.Walk(fn)
+}
+
 func CheckConcurrentTesting(f *lint.File) {
 	fn := func(node ast.Node) bool {
 		fn, ok := node.(*ast.FuncDecl)
@@ -2047,11 +2585,69 @@ func CheckSliceOutOfBounds(f *lint.File) {
 				}
 			}
 		}
+		checkProvablyOutOfBounds(f, ssafn)
 		return true
 	}
 	f.Walk(fn)
 }
 
+// checkProvablyOutOfBounds complements the constant-index cases handled
+// directly in CheckSliceOutOfBounds: using vrp.Ranges, it flags an index
+// expression with a non-constant index whose value range is always
+// negative, or, when the indexed value is an array or a pointer to one,
+// always at least the array's length. Slice expressions ("s[i:j]") are
+// not checked, and for slices and strings only an always-negative index
+// is flagged.
+func checkProvablyOutOfBounds(f *lint.File, ssafn *ssa.Function) {
+	ranges := vrp.Ranges(ssafn)
+	zero := big.NewInt(0)
+	checkIndex := func(ins ssa.Instruction, index, x ssa.Value) {
+		idxRange, ok := ranges[index]
+		if !ok || !idxRange.Known() {
+			return
+		}
+		if idxRange.Hi != nil && idxRange.Hi.Cmp(zero) < 0 {
+			f.Errorf(ins, "index out of bounds: always negative")
+			return
+		}
+		n, ok := staticLen(x)
+		if !ok {
+			return
+		}
+		if idxRange.Lo != nil && idxRange.Lo.Cmp(big.NewInt(n)) >= 0 {
+			f.Errorf(ins, "index out of bounds: index is always >= %d, but length is %d", idxRange.Lo, n)
+		}
+	}
+	for _, block := range ssafn.Blocks {
+		for _, ins := range block.Instrs {
+			switch instr := ins.(type) {
+			case *ssa.IndexAddr:
+				if _, isConst := instr.Index.(*ssa.Const); !isConst {
+					checkIndex(instr, instr.Index, instr.X)
+				}
+			case *ssa.Index:
+				if _, isConst := instr.Index.(*ssa.Const); !isConst {
+					checkIndex(instr, instr.Index, instr.X)
+				}
+			}
+		}
+	}
+}
+
+// staticLen returns the fixed length of x, if x is an array or a pointer
+// to one; slices and strings don't have a length known without running
+// the program, so they aren't handled here.
+func staticLen(x ssa.Value) (int64, bool) {
+	typ := x.Type()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	if arr, ok := typ.Underlying().(*types.Array); ok {
+		return arr.Len(), true
+	}
+	return 0, false
+}
+
 func CheckDeferLock(f *lint.File) {
 	fn := func(node ast.Node) bool {
 		block, ok := node.(*ast.BlockStmt)
@@ -2114,35 +2710,191 @@ func CheckNaNComparison(f *lint.File) {
 	f.Walk(fn)
 }
 
+// callGraphSCCs partitions every function reachable from pkg's members
+// (following anonymous function literals, but only statically
+// resolvable, non-interface calls) into strongly connected components
+// of the direct call graph, using Tarjan's algorithm. The result maps
+// each function to an arbitrary but stable ID shared by every other
+// member of its component; two functions are mutually recursive, direct
+// or indirect, exactly when they share an ID.
+func callGraphSCCs(pkg *ssa.Package) map[*ssa.Function]int {
+	var all []*ssa.Function
+	seen := map[*ssa.Function]bool{}
+	var collect func(fn *ssa.Function)
+	collect = func(fn *ssa.Function) {
+		if fn == nil || seen[fn] {
+			return
+		}
+		seen[fn] = true
+		all = append(all, fn)
+		for _, anon := range fn.AnonFuncs {
+			collect(anon)
+		}
+	}
+	for _, member := range pkg.Members {
+		if fn, ok := member.(*ssa.Function); ok {
+			collect(fn)
+		}
+	}
+
+	successors := func(fn *ssa.Function) []*ssa.Function {
+		var out []*ssa.Function
+		for _, block := range fn.Blocks {
+			for _, ins := range block.Instrs {
+				call, ok := ins.(*ssa.Call)
+				if !ok || call.Common().IsInvoke() {
+					continue
+				}
+				if callee, ok := call.Common().Value.(*ssa.Function); ok {
+					out = append(out, callee)
+				}
+			}
+		}
+		return out
+	}
+
+	type tstate struct {
+		index, low int
+		onStack    bool
+	}
+	state := map[*ssa.Function]*tstate{}
+	var stack []*ssa.Function
+	next, sccID := 0, 0
+	sccOf := map[*ssa.Function]int{}
+
+	var strongconnect func(fn *ssa.Function)
+	strongconnect = func(fn *ssa.Function) {
+		st := &tstate{index: next, low: next, onStack: true}
+		state[fn] = st
+		next++
+		stack = append(stack, fn)
+
+		for _, succ := range successors(fn) {
+			sst, ok := state[succ]
+			if !ok {
+				strongconnect(succ)
+				if state[succ].low < st.low {
+					st.low = state[succ].low
+				}
+			} else if sst.onStack && sst.index < st.low {
+				st.low = sst.index
+			}
+		}
+
+		if st.low == st.index {
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				state[top].onStack = false
+				sccOf[top] = sccID
+				if top == fn {
+					break
+				}
+			}
+			sccID++
+		}
+	}
+
+	for _, fn := range all {
+		if _, ok := state[fn]; !ok {
+			strongconnect(fn)
+		}
+	}
+	return sccOf
+}
+
+// sccCanExit reports whether any function in members contains a call
+// that legitimately never returns to its caller -- a panic, or a call
+// to a function for which functions.Analysis has already proven
+// NeverReturns, such as os.Exit or log.Fatal. A cycle that always ends
+// this way isn't an infinite-recursion bug, it's the intended way to
+// terminate the program or the goroutine.
+func sccCanExit(members []*ssa.Function) bool {
+	for _, fn := range members {
+		for _, block := range fn.Blocks {
+			for _, ins := range block.Instrs {
+				if _, ok := ins.(*ssa.Panic); ok {
+					return true
+				}
+				call, ok := ins.(*ssa.Call)
+				if !ok || call.Common().IsInvoke() {
+					continue
+				}
+				if callee, ok := call.Common().Value.(*ssa.Function); ok && factsForSSA(callee).NeverReturns {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// CheckInfiniteRecursion flags a call that can only ever recurse,
+// whether directly (a function calling itself) or through a cycle of
+// mutual calls such as A calling B calling A. It works by partitioning
+// the package's call graph into strongly connected components with
+// callGraphSCCs: a call site whose callee is in the same component as
+// its caller is a candidate, and is reported unless the caller itself
+// has some other reachable return that doesn't go through the
+// recursive call, or the component can legitimately exit via panic,
+// os.Exit, log.Fatal, or similar.
 func CheckInfiniteRecursion(f *lint.File) {
+	sccs := callGraphSCCs(f.Pkg.SSAPkg)
+	members := map[int][]*ssa.Function{}
+	for fn, id := range sccs {
+		members[id] = append(members[id], fn)
+	}
+
 	fn := func(node ast.Node) bool {
-		fn, ok := node.(*ast.FuncDecl)
+		decl, ok := node.(*ast.FuncDecl)
 		if !ok {
 			return true
 		}
-		ssafn := f.EnclosingSSAFunction(fn)
-		if ssafn == nil {
+		ssafn := f.EnclosingSSAFunction(decl)
+		if ssafn == nil || len(ssafn.Blocks) == 0 {
 			return true
 		}
-		if len(ssafn.Blocks) == 0 {
+		id, ok := sccs[ssafn]
+		if !ok {
+			return true
+		}
+
+		if len(members[id]) == 1 {
+			// A singleton component is only "recursive" if its one
+			// member calls itself directly; otherwise it's an
+			// ordinary acyclic function.
+			self := false
+			for _, block := range ssafn.Blocks {
+				for _, ins := range block.Instrs {
+					if call, ok := ins.(*ssa.Call); ok && !call.Common().IsInvoke() {
+						if callee, ok := call.Common().Value.(*ssa.Function); ok && callee == ssafn {
+							self = true
+						}
+					}
+				}
+			}
+			if !self {
+				return true
+			}
+		}
+
+		if sccCanExit(members[id]) {
 			return true
 		}
+
 		for _, block := range ssafn.Blocks {
 			for _, ins := range block.Instrs {
 				call, ok := ins.(*ssa.Call)
-				if !ok {
-					continue
-				}
-				if call.Common().IsInvoke() {
+				if !ok || call.Common().IsInvoke() {
 					continue
 				}
 				subfn, ok := call.Common().Value.(*ssa.Function)
-				if !ok || subfn != ssafn {
+				if !ok || sccs[subfn] != id {
 					continue
 				}
 
 				canReturn := false
-				for _, b := range subfn.Blocks {
+				for _, b := range ssafn.Blocks {
 					if block.Dominates(b) {
 						continue
 					}
@@ -2157,7 +2909,12 @@ func CheckInfiniteRecursion(f *lint.File) {
 				if canReturn {
 					continue
 				}
-				f.Errorf(call, "infinite recursive call")
+
+				if subfn == ssafn {
+					f.Errorf(call, "infinite recursive call")
+				} else {
+					f.Errorf(call, "infinite recursive call via %s", subfn.Name())
+				}
 			}
 		}
 		return true
@@ -2165,69 +2922,114 @@ func CheckInfiniteRecursion(f *lint.File) {
 	f.Walk(fn)
 }
 
-func isTypeName(f *lint.File, node ast.Node, pkgName, name string) bool {
-	call, ok := node.(*ast.CallExpr)
-	if !ok {
-		return false
-	}
-	sel, ok := call.Fun.(*ast.SelectorExpr)
-	if !ok {
-		return false
-	}
-	tn, ok := f.Pkg.TypesInfo.ObjectOf(sel.Sel).(*types.TypeName)
-	return ok && tn.Pkg().Name() == pkgName && tn.Name() == name
+// UnmarshalFuncs lists every "decode into this argument" function
+// CheckUnmarshalPointer knows about, keyed by the callee's full name as
+// returned by (*types.Func).FullName, each mapped to the index of the
+// argument that receives the decoded value (negative indices count from
+// the end, following Call.Arg). A project that vendors its own decoder
+// can add an entry here -- there's no staticcheck configuration file
+// this table is wired up to yet, so for now that means a package-level
+// assignment rather than an on-disk config entry.
+var UnmarshalFuncs = map[string]int{
+	"encoding/xml.Unmarshal":                       -1,
+	"(*encoding/xml.Decoder).Decode":               -1,
+	"encoding/json.Unmarshal":                      -1,
+	"(*encoding/json.Decoder).Decode":              -1,
+	"(*encoding/gob.Decoder).Decode":               -1,
+	"gopkg.in/yaml.v2.Unmarshal":                   -1,
+	"gopkg.in/yaml.v3.Unmarshal":                   -1,
+	"(*gopkg.in/yaml.v2.Decoder).Decode":           -1,
+	"(*gopkg.in/yaml.v3.Decoder).Decode":           -1,
+	"github.com/BurntSushi/toml.Unmarshal":         -1,
+	"github.com/BurntSushi/toml.Decode":            -1,
+	"(*github.com/BurntSushi/toml.Decoder).Decode": -1,
+	"google.golang.org/protobuf/proto.Unmarshal":   -1,
+	"go.mongodb.org/mongo-driver/bson.Unmarshal":   -1,
 }
 
-func isFunctionCallName(f *lint.File, node ast.Node, name string) bool {
-	call, ok := node.(*ast.CallExpr)
-	if !ok {
-		return false
-	}
-	sel, ok := call.Fun.(*ast.SelectorExpr)
-	if !ok {
-		return false
+func CheckUnmarshalPointer(f *lint.File) {
+	rules := make(map[string]CallRule, len(UnmarshalFuncs))
+	for name, idx := range UnmarshalFuncs {
+		rules[name] = pointerArg(idx)
 	}
-	fn, ok := f.Pkg.TypesInfo.ObjectOf(sel.Sel).(*types.Func)
-	return ok && fn.FullName() == name
+	checkCalls(f, rules)
 }
 
-func isFunctionCallNameAny(f *lint.File, node ast.Node, names []string) bool {
-	for _, name := range names {
-		if isFunctionCallName(f, node, name) {
-			return true
+// isStaticallyNil reports whether val is provably nil: either a nil
+// constant directly, or a load from a local variable (boxed in an
+// *ssa.Alloc because its address is taken somewhere) that's never
+// stored a non-nil value.
+func isStaticallyNil(val ssa.Value) bool {
+	if unop, ok := val.(*ssa.UnOp); ok && unop.Op == token.MUL {
+		val = unop.X
+	}
+	switch val := val.(type) {
+	case *ssa.Const:
+		return val.IsNil()
+	case *ssa.Alloc:
+		refs := val.Referrers()
+		if refs == nil {
+			return false
+		}
+		for _, ref := range FilterDebug(*refs) {
+			store, ok := ref.(*ssa.Store)
+			if !ok || store.Addr != val {
+				continue
+			}
+			c, ok := store.Val.(*ssa.Const)
+			if !ok || !c.IsNil() {
+				return false
+			}
 		}
+		// Either every store writes nil, or there are no stores at
+		// all -- in which case the variable keeps its zero value,
+		// which for a pointer or interface is nil too.
+		return true
 	}
 	return false
 }
 
-func CheckUnmarshalPointer(f *lint.File) {
-	names := []string{
-		"encoding/xml.Unmarshal",
-		"(*encoding/xml.Decoder).Decode",
-		"encoding/json.Unmarshal",
-		"(*encoding/json.Decoder).Decode",
-	}
+// CheckUnmarshalNilPointer is the companion to CheckUnmarshalPointer:
+// where that check flags a value that isn't a pointer or interface at
+// all, this one flags the narrower but just as panic-prone case of a
+// pointer or interface argument that's statically known to be nil --
+// "var p *T; json.Unmarshal(b, p)", or an explicit untyped nil -- which
+// passes the pointer/interface check but still panics deep inside the
+// decoder with a message that rarely points back at the real mistake.
+func CheckUnmarshalNilPointer(f *lint.File) {
 	fn := func(node ast.Node) bool {
 		call, ok := node.(*ast.CallExpr)
 		if !ok {
 			return true
 		}
-		sel, ok := call.Fun.(*ast.SelectorExpr)
+		name, fnObj := calleeName(f, call)
+		if fnObj == nil {
+			return true
+		}
+		idx, ok := UnmarshalFuncs[name]
 		if !ok {
-			return false
+			return true
 		}
-		if len(call.Args) == 0 {
+		i := idx
+		if i < 0 {
+			i = len(call.Args) + i
+		}
+		if i < 0 || i >= len(call.Args) {
 			return true
 		}
-		if !isFunctionCallNameAny(f, call, names) {
+		arg := call.Args[i]
+
+		ssafn := f.EnclosingSSAFunction(call)
+		if ssafn == nil {
 			return true
 		}
-		arg := call.Args[len(call.Args)-1]
-		switch f.Pkg.TypesInfo.TypeOf(arg).Underlying().(type) {
-		case *types.Pointer, *types.Interface:
+		val, _ := ssafn.ValueForExpr(arg)
+		if val == nil {
 			return true
 		}
-		f.Errorf(arg, "%s expects to unmarshal into a pointer, but the provided value is not a pointer", sel.Sel.Name)
+		if isStaticallyNil(val) {
+			f.Errorf(arg, "%s will panic: the argument is always nil", fnObj.Name())
+		}
 		return true
 	}
 	f.Walk(fn)
@@ -0,0 +1,123 @@
+// Package lintdsl provides small, generic building blocks -- resolving
+// a call's callee, comparing a type against a qualified name, filtering
+// debug instructions out of an SSA block, and the like -- that used to
+// be copied, slightly differently, into every check that needed them.
+// It's meant to be dot-imported, so that a check reads declaratively:
+//
+//	if IsCallTo(call.Common(), "encoding/json.Unmarshal") {
+//		...
+//	}
+package lintdsl
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+	"honnef.co/go/lint"
+)
+
+// CallName returns the full name of the function a call invokes, as
+// returned by (*types.Func).FullName, or "" if the callee isn't a
+// statically known *ssa.Function or *ssa.Builtin.
+func CallName(call *ssa.CallCommon) string {
+	if call.IsInvoke() {
+		return ""
+	}
+	switch v := call.Value.(type) {
+	case *ssa.Function:
+		fn, ok := v.Object().(*types.Func)
+		if !ok {
+			return ""
+		}
+		return fn.FullName()
+	case *ssa.Builtin:
+		return v.Name()
+	}
+	return ""
+}
+
+// IsCallTo reports whether call invokes the function named name.
+func IsCallTo(call *ssa.CallCommon, name string) bool {
+	return CallName(call) == name
+}
+
+// IsType reports whether T's string representation is name -- the same
+// comparison (*types.Func).FullName and CallName already use for
+// functions, applied to a type instead.
+func IsType(T types.Type, name string) bool {
+	return T.String() == name
+}
+
+// IsPointerLike reports whether T is a pointer, or an interface --
+// the two kinds of type a nil value can inhabit without a wrapping
+// allocation, and so the two kinds of type an "unmarshal into this"
+// API can write through.
+func IsPointerLike(T types.Type) bool {
+	switch T.Underlying().(type) {
+	case *types.Interface, *types.Pointer:
+		return true
+	}
+	return false
+}
+
+// FilterDebug returns instrs with every *ssa.DebugRef removed, the
+// instruction kind go/ssa emits purely to let a debugger map a value
+// back to source and which every check that walks raw instructions
+// needs to skip.
+func FilterDebug(instrs []ssa.Instruction) []ssa.Instruction {
+	var out []ssa.Instruction
+	for _, ins := range instrs {
+		if _, ok := ins.(*ssa.DebugRef); !ok {
+			out = append(out, ins)
+		}
+	}
+	return out
+}
+
+// IsExample reports whether fn looks like a testing example function:
+// no arguments, no results, and a name of the form "Example" or
+// "Example_xxx" (the same rule go test uses to recognize examples).
+func IsExample(fn *ssa.Function) bool {
+	if fn.Name() == "Example" {
+		return true
+	}
+	if !strings.HasPrefix(fn.Name(), "Example") {
+		return false
+	}
+	sig := fn.Signature
+	return sig.Params().Len() == 0 && sig.Results().Len() == 0
+}
+
+// IsTypeName reports whether node is a conversion or call to the named
+// type declared in package pkgName, resolving the selector through
+// f.Pkg.TypesInfo.
+func IsTypeName(f *lint.File, node ast.Node, pkgName, name string) bool {
+	call, ok := node.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	tn, ok := f.Pkg.TypesInfo.ObjectOf(sel.Sel).(*types.TypeName)
+	return ok && tn.Pkg().Name() == pkgName && tn.Name() == name
+}
+
+// IsFunctionCallName reports whether node is a call whose callee's
+// full name (as returned by (*types.Func).FullName) is name, resolving
+// the selector through f.Pkg.TypesInfo.
+func IsFunctionCallName(f *lint.File, node ast.Node, name string) bool {
+	call, ok := node.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	fn, ok := f.Pkg.TypesInfo.ObjectOf(sel.Sel).(*types.Func)
+	return ok && fn.FullName() == name
+}
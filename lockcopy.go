@@ -0,0 +1,129 @@
+package staticcheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"honnef.co/go/lint"
+)
+
+// typeContainsLock reports whether typ (or, recursively, one of its struct
+// fields or array elements) contains a sync.Locker. Slices, maps and
+// channels are references and are deliberately not recursed into: copying
+// them does not copy whatever they point to.
+func typeContainsLock(typ types.Type) bool {
+	return typeContainsLock1(typ, map[types.Type]bool{})
+}
+
+func typeContainsLock1(typ types.Type, seen map[types.Type]bool) bool {
+	if typ == nil || seen[typ] {
+		return false
+	}
+	seen[typ] = true
+
+	if _, ok := typ.(*types.Pointer); ok {
+		// A pointer is a reference: copying it copies the pointer, not
+		// whatever it points to, so it never "contains" a lock the way a
+		// struct or array that embeds one by value does. Without this,
+		// *sync.Mutex itself would match below, since its pointer
+		// receiver methods make it satisfy sync.Locker directly -- which
+		// would flag the standard, safe way to share a mutex (foo(&mu),
+		// ranging over []*sync.Mutex, returning &mu) as copying a lock.
+		return false
+	}
+
+	if implementsLocker(typ) {
+		return true
+	}
+
+	switch typ := typ.Underlying().(type) {
+	case *types.Struct:
+		for i := 0; i < typ.NumFields(); i++ {
+			if typeContainsLock1(typ.Field(i).Type(), seen) {
+				return true
+			}
+		}
+	case *types.Array:
+		return typeContainsLock1(typ.Elem(), seen)
+	}
+	return false
+}
+
+var lockerIface = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(0, nil, "Lock", types.NewSignature(nil, nil, nil, false)),
+	types.NewFunc(0, nil, "Unlock", types.NewSignature(nil, nil, nil, false)),
+}, nil).Complete()
+
+// implementsLocker reports whether typ or *typ implements sync.Locker, i.e.
+// has Lock/Unlock methods -- this catches sync.Mutex, sync.RWMutex and
+// anything embedding them, without needing to import "sync".
+func implementsLocker(typ types.Type) bool {
+	if types.Implements(typ, lockerIface) {
+		return true
+	}
+	return types.Implements(types.NewPointer(typ), lockerIface)
+}
+
+// CheckLockCopy flags constructs that implicitly copy a value whose type
+// contains a lock (sync.Mutex, sync.RWMutex, or anything else implementing
+// sync.Locker): ranging over a slice/array of such values, receiving one
+// from a channel, passing one by value to a function, and returning one by
+// value. Copying a lock is almost always a bug -- the copy and the original
+// no longer share state, so protection silently stops working.
+func CheckLockCopy(f *lint.File) {
+	flagExpr := func(expr ast.Expr, desc string) {
+		typ := f.Pkg.TypesInfo.TypeOf(expr)
+		if typ == nil || !typeContainsLock(typ) {
+			return
+		}
+		f.Errorf(expr, "%s copies a lock value of type %s: %s", desc, typ, f.Render(expr))
+	}
+
+	fn := func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.RangeStmt:
+			typ := f.Pkg.TypesInfo.TypeOf(node.X)
+			if typ == nil {
+				return true
+			}
+			var elem types.Type
+			switch u := typ.Underlying().(type) {
+			case *types.Slice:
+				elem = u.Elem()
+			case *types.Array:
+				elem = u.Elem()
+			case *types.Map:
+				elem = u.Elem()
+			default:
+				return true
+			}
+			if node.Value == nil || !typeContainsLock(elem) {
+				return true
+			}
+			f.Errorf(node.Value, "range statement copies a lock value of type %s", elem)
+		case *ast.UnaryExpr:
+			if node.Op == token.ARROW {
+				flagExpr(node, "channel receive")
+			}
+		case *ast.CallExpr:
+			sig, _ := f.Pkg.TypesInfo.TypeOf(node.Fun).(*types.Signature)
+			if sig == nil {
+				return true
+			}
+			params := sig.Params()
+			for i, arg := range node.Args {
+				if i >= params.Len() {
+					break
+				}
+				flagExpr(arg, "function call")
+			}
+		case *ast.ReturnStmt:
+			for _, result := range node.Results {
+				flagExpr(result, "return statement")
+			}
+		}
+		return true
+	}
+	f.Walk(fn)
+}
@@ -0,0 +1,710 @@
+package staticcheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"honnef.co/go/lint"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// mutexOp describes what a call to a sync.Locker-like method does to the
+// lock-set.
+type mutexOp int
+
+const (
+	mutexNone mutexOp = iota
+	mutexAcquire
+	mutexRelease
+)
+
+// mutexMethods maps the full name of a balanced acquire/release method (as
+// returned by (*types.Func).FullName) to the effect calling it has on the
+// lock-set. Embedding is transparent here: calling Lock on a type that
+// embeds sync.Mutex still resolves to "(*sync.Mutex).Lock".
+//
+// This isn't limited to sync.Mutex/RWMutex: any type that provides true
+// mutual exclusion can be added here, which lets the same analysis catch
+// leaked semaphores and OS-level file locks, not just in-process mutexes.
+// golang.org/x/sync/singleflight is deliberately absent: its Do calls are
+// self-contained and don't need balancing, so adding it would only produce
+// false positives.
+//
+// Anything whose acquire/release pair doesn't actually exclude other
+// goroutines -- sync.WaitGroup being the prime example -- does not belong
+// in this table: CheckLockAnnotations and CheckMutexBlockingOp walk lock
+// sets built from exactly this table to detect recursive locking and
+// blocking-while-held, and both would misfire on it (wg.Add(1) called
+// twice before any Done() is not a recursive lock, and wg.Wait() after an
+// Add() is not blocking while holding a mutex). See waitGroupMethods.
+var mutexMethods = map[string]mutexOp{
+	"(*sync.Mutex).Lock":      mutexAcquire,
+	"(*sync.Mutex).Unlock":    mutexRelease,
+	"(*sync.RWMutex).Lock":    mutexAcquire,
+	"(*sync.RWMutex).Unlock":  mutexRelease,
+	"(*sync.RWMutex).RLock":   mutexAcquire,
+	"(*sync.RWMutex).RUnlock": mutexRelease,
+
+	"(*golang.org/x/sync/semaphore.Weighted).Acquire": mutexAcquire,
+	"(*golang.org/x/sync/semaphore.Weighted).Release": mutexRelease,
+
+	"(*github.com/gofrs/flock.Flock).Lock":   mutexAcquire,
+	"(*github.com/gofrs/flock.Flock).Unlock": mutexRelease,
+}
+
+// waitGroupMethods treats (*sync.WaitGroup).Add/Done as a balanced
+// acquire/release pair purely so CheckReturnBeforeMutexUnlock can flag a
+// function that returns having Add()ed without a matching Done() -- the
+// same kind of leak as returning with a mutex still held. It must stay out
+// of mutexMethods; see the comment there for why.
+var waitGroupMethods = map[string]mutexOp{
+	"(*sync.WaitGroup).Add":  mutexAcquire,
+	"(*sync.WaitGroup).Done": mutexRelease,
+}
+
+// leakMethods is the table CheckReturnBeforeMutexUnlock walks lock sets
+// with: true mutexes, so a forgotten Unlock is still caught, plus
+// waitGroupMethods, so a forgotten Done is caught too.
+var leakMethods = mergeMutexMethods(mutexMethods, waitGroupMethods)
+
+func mergeMutexMethods(tables ...map[string]mutexOp) map[string]mutexOp {
+	out := make(map[string]mutexOp, len(tables)*8)
+	for _, table := range tables {
+		for name, op := range table {
+			out[name] = op
+		}
+	}
+	return out
+}
+
+// mutexCallOp returns the lock-set effect of calling call according to
+// methods, along with the SSA value identifying the mutex that was locked
+// or unlocked.
+func mutexCallOp(methods map[string]mutexOp, call *ssa.CallCommon) (mutexOp, ssa.Value) {
+	if call == nil || call.IsInvoke() || len(call.Args) == 0 {
+		return mutexNone, nil
+	}
+	callee := call.StaticCallee()
+	if callee == nil {
+		return mutexNone, nil
+	}
+	fn, ok := callee.Object().(*types.Func)
+	if !ok {
+		return mutexNone, nil
+	}
+	op, ok := methods[fn.FullName()]
+	if !ok {
+		return mutexNone, nil
+	}
+	return op, call.Args[0]
+}
+
+// sameMutex reports whether a and b denote the same mutex. Besides plain
+// SSA value identity, it also recognizes repeated field selections of the
+// same field off the same base (e.g. two independent "x.mu" FieldAddrs),
+// which aren't otherwise unified by go/ssa.
+func sameMutex(a, b ssa.Value) bool {
+	if a == b {
+		return true
+	}
+	fa, ok1 := a.(*ssa.FieldAddr)
+	fb, ok2 := b.(*ssa.FieldAddr)
+	if ok1 && ok2 && fa.Field == fb.Field {
+		return sameMutex(fa.X, fb.X)
+	}
+	return false
+}
+
+func lockSetHas(set []ssa.Value, v ssa.Value) bool {
+	for _, held := range set {
+		if sameMutex(held, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func lockSetRemove(set []ssa.Value, v ssa.Value) []ssa.Value {
+	out := set[:0]
+	for _, held := range set {
+		if !sameMutex(held, v) {
+			out = append(out, held)
+		}
+	}
+	return out
+}
+
+// calleeReleases reports whether calling callee with the given arguments
+// (receiver included, as in ssa.CallCommon.Args) and free-variable bindings
+// (as in ssa.MakeClosure.Bindings, nil for an ordinary function) releases
+// target: somewhere in callee's body, Unlock (or another release op) is
+// called on the parameter or captured variable that target was passed in
+// as. Only statically resolvable callees are considered; calleeReleases
+// does not recurse further than one level, which is enough to cover the
+// common "defer fn()" / "func() { mu.Unlock() }()" helper patterns without
+// risking non-termination on recursive helpers.
+func calleeReleases(methods map[string]mutexOp, callee *ssa.Function, args, bindings []ssa.Value, target ssa.Value) bool {
+	if callee == nil || len(callee.Blocks) == 0 {
+		return false
+	}
+	mapsToTarget := func(v ssa.Value) bool {
+		switch v := v.(type) {
+		case *ssa.Parameter:
+			for i, p := range callee.Params {
+				if p == v && i < len(args) {
+					return sameMutex(args[i], target)
+				}
+			}
+		case *ssa.FreeVar:
+			for i, fv := range callee.FreeVars {
+				if fv == v && i < len(bindings) {
+					return sameMutex(bindings[i], target)
+				}
+			}
+		}
+		return false
+	}
+	for _, block := range callee.Blocks {
+		for _, instr := range block.Instrs {
+			call, ok := instr.(*ssa.Call)
+			if !ok {
+				continue
+			}
+			if op, mv := mutexCallOp(methods, call.Common()); op == mutexRelease && mapsToTarget(mv) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// calledClosure extracts the statically known *ssa.Function and free-var
+// bindings invoked by a call or defer, looking through ssa.MakeClosure.
+func calledClosure(v ssa.Value) (*ssa.Function, []ssa.Value) {
+	switch v := v.(type) {
+	case *ssa.Function:
+		return v, nil
+	case *ssa.MakeClosure:
+		if fn, ok := v.Fn.(*ssa.Function); ok {
+			return fn, v.Bindings
+		}
+	}
+	return nil, nil
+}
+
+// deferredUnlocks returns the set of mutexes that are unlocked by a defer
+// statement somewhere in fn, either directly ("defer mu.Unlock()") or via a
+// deferred call to a closure or named helper function that unlocks it
+// ("defer func() { mu.Unlock() }()", "defer unlockFn()"). Once a mutex is
+// deferred-unlocked we treat it as held-until-return for the rest of the
+// function, since the defer is guaranteed to run.
+func deferredUnlocks(methods map[string]mutexOp, fn *ssa.Function) []ssa.Value {
+	var out []ssa.Value
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			def, ok := instr.(*ssa.Defer)
+			if !ok {
+				continue
+			}
+			if op, mv := mutexCallOp(methods, def.Common()); op == mutexRelease {
+				out = append(out, mv)
+				continue
+			}
+			callee, bindings := calledClosure(def.Call.Value)
+			if callee == nil {
+				continue
+			}
+			for _, held := range candidateMutexes(methods, fn) {
+				if calleeReleases(methods, callee, def.Call.Args, bindings, held) {
+					out = append(out, held)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// candidateMutexes returns every value that's the receiver of a Lock/RLock
+// (or other acquire) call anywhere in fn, used as the search space when
+// checking whether a callee releases "some mutex the caller holds".
+func candidateMutexes(methods map[string]mutexOp, fn *ssa.Function) []ssa.Value {
+	var out []ssa.Value
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			call, ok := instr.(*ssa.Call)
+			if !ok {
+				continue
+			}
+			if op, mv := mutexCallOp(methods, call.Common()); op == mutexAcquire {
+				out = append(out, mv)
+			}
+		}
+	}
+	return out
+}
+
+// intersectLockSets returns the values present in every set in sets, i.e.
+// the merge of several predecessors' lock-sets at a CFG join point: a
+// mutex is only held after the join if it was held coming from every
+// predecessor.
+func intersectLockSets(sets [][]ssa.Value) []ssa.Value {
+	if len(sets) == 0 {
+		return nil
+	}
+	var out []ssa.Value
+	for _, v := range sets[0] {
+		heldEverywhere := true
+		for _, s := range sets[1:] {
+			if !lockSetHas(s, v) {
+				heldEverywhere = false
+				break
+			}
+		}
+		if heldEverywhere {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// lockSetsEqual reports whether a and b hold the same mutexes, ignoring
+// order.
+func lockSetsEqual(a, b []ssa.Value) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, v := range a {
+		if !lockSetHas(b, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// blockEntry computes a block's entry lock-set as the intersection of its
+// predecessors' exit sets. A predecessor not yet present in exit (only
+// possible mid-fixed-point, before every block has been visited once) is
+// treated as top -- the universal set of every mutex the function ever
+// acquires -- rather than as holding nothing, so an not-yet-computed
+// back-edge predecessor can't spuriously empty out a loop header's
+// lock-set.
+func blockEntry(block *ssa.BasicBlock, exit map[*ssa.BasicBlock][]ssa.Value, visited map[*ssa.BasicBlock]bool, top []ssa.Value) []ssa.Value {
+	if len(block.Preds) == 0 {
+		return nil
+	}
+	sets := make([][]ssa.Value, len(block.Preds))
+	for i, pred := range block.Preds {
+		if visited[pred] {
+			sets[i] = exit[pred]
+		} else {
+			sets[i] = top
+		}
+	}
+	return intersectLockSets(sets)
+}
+
+// simulateBlock applies the effect of block's instructions to locked,
+// calling visit (if non-nil) for every instruction with the lock-set as it
+// stood before that instruction executed, and returns the lock-set as it
+// stands after the last instruction.
+func simulateBlock(methods map[string]mutexOp, deferred, locked []ssa.Value, block *ssa.BasicBlock, visit func(locked []ssa.Value, instr ssa.Instruction)) []ssa.Value {
+	for _, instr := range block.Instrs {
+		if visit != nil {
+			visit(locked, instr)
+		}
+		call, ok := instr.(*ssa.Call)
+		if !ok {
+			continue
+		}
+		switch op, mv := mutexCallOp(methods, call.Common()); op {
+		case mutexAcquire:
+			if !lockSetHas(locked, mv) {
+				locked = append(locked, mv)
+			}
+		case mutexRelease:
+			if !lockSetHas(deferred, mv) {
+				locked = lockSetRemove(locked, mv)
+			}
+		case mutexNone:
+			// Not a direct acquire/release; it might still be a
+			// statically resolvable helper (or immediately invoked
+			// closure) that unlocks one of the mutexes we're holding.
+			callee, bindings := calledClosure(call.Common().Value)
+			for _, held := range append([]ssa.Value(nil), locked...) {
+				if lockSetHas(deferred, held) {
+					continue
+				}
+				if calleeReleases(methods, callee, call.Common().Args, bindings, held) {
+					locked = lockSetRemove(locked, held)
+				}
+			}
+		}
+	}
+	return locked
+}
+
+// walkLockSets computes, for every instruction in fn, an approximation of
+// the lock-set held just before it executes (the set of values, among
+// those balanced acquire/release methods listed in methods, for which an
+// acquire dominates the current instruction without an intervening
+// release on the same value), and invokes visit with that lock-set for
+// every instruction.
+//
+// Because a loop header has a back-edge predecessor that hasn't been
+// processed yet the first time the header itself is reached, a single
+// dominance-ordered pass can't compute this correctly: it has to either
+// skip that predecessor (wrongly treating "not yet computed" as "holds
+// nothing", which empties out the lock-set at any loop header with two or
+// more predecessors even when a lock was held on entry to the loop) or
+// get another chance at it later. walkLockSets instead iterates to a
+// fixed point -- revisiting every block and recomputing its entry/exit
+// lock-sets from its (possibly still-updating) predecessors -- until
+// nothing changes, and only then replays the blocks once more to call
+// visit with the final, stable lock-sets.
+func walkLockSets(methods map[string]mutexOp, fn *ssa.Function, visit func(locked []ssa.Value, instr ssa.Instruction)) {
+	if len(fn.Blocks) == 0 {
+		return
+	}
+	deferred := deferredUnlocks(methods, fn)
+	top := candidateMutexes(methods, fn)
+
+	exit := make(map[*ssa.BasicBlock][]ssa.Value, len(fn.Blocks))
+	visited := make(map[*ssa.BasicBlock]bool, len(fn.Blocks))
+
+	// The lattice (subsets of top, ordered by inclusion, merged by
+	// intersection) is finite and the per-block transfer function is
+	// monotone, so this is guaranteed to reach a fixed point; the cap
+	// below is just a backstop against a latent bug turning this into an
+	// infinite loop.
+	maxRounds := 4*len(fn.Blocks) + 4
+	for round := 0; round < maxRounds; round++ {
+		changed := false
+		for _, block := range fn.Blocks {
+			entry := blockEntry(block, exit, visited, top)
+			newExit := simulateBlock(methods, deferred, entry, block, nil)
+			if !visited[block] || !lockSetsEqual(exit[block], newExit) {
+				changed = true
+			}
+			exit[block] = newExit
+			visited[block] = true
+		}
+		if !changed {
+			break
+		}
+	}
+
+	for _, block := range fn.Blocks {
+		entry := blockEntry(block, exit, visited, top)
+		simulateBlock(methods, deferred, entry, block, visit)
+	}
+}
+
+// CheckReturnBeforeMutexUnlock flags returning from a function (or a
+// function literal) while a sync.Mutex/RWMutex acquired earlier in the
+// same function is still held and no deferred call releases it, which is
+// almost always a bug: the caller never observes the lock being released.
+func CheckReturnBeforeMutexUnlock(f *lint.File) {
+	fn := func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+		default:
+			return true
+		}
+		ssafn := f.EnclosingSSAFunction(node)
+		if ssafn == nil {
+			return true
+		}
+		deferred := deferredUnlocks(leakMethods, ssafn)
+		walkLockSets(leakMethods, ssafn, func(locked []ssa.Value, instr ssa.Instruction) {
+			if _, ok := instr.(*ssa.Return); !ok {
+				return
+			}
+			for _, held := range locked {
+				if !lockSetHas(deferred, held) {
+					f.Errorf(instr, "return before mutex unlock")
+					return
+				}
+			}
+		})
+		return true
+	}
+	f.Walk(fn)
+}
+
+// lockedField describes a struct field annotated with "+checklocks:mu",
+// declaring that it must only be accessed while the field named mu (on the
+// same struct) is held.
+type lockedField struct {
+	field *types.Var
+	mutex string
+}
+
+// checklocksFields finds all "+checklocks:mu" annotated fields of the
+// struct types declared at the top level of f.
+func checklocksFields(f *lint.File) map[*types.Var]string {
+	out := map[*types.Var]string{}
+	for _, decl := range f.File.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok || st.Fields == nil {
+				continue
+			}
+			for _, field := range st.Fields.List {
+				mu := checklocksAnnotation(field.Doc, "+checklocks:")
+				if mu == "" {
+					mu = checklocksAnnotation(field.Comment, "+checklocks:")
+				}
+				if mu == "" {
+					continue
+				}
+				for _, name := range field.Names {
+					obj, ok := f.Pkg.TypesInfo.ObjectOf(name).(*types.Var)
+					if ok {
+						out[obj] = mu
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+func checklocksAnnotation(doc *ast.CommentGroup, prefix string) string {
+	if doc == nil {
+		return ""
+	}
+	for _, c := range doc.List {
+		text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+		if strings.HasPrefix(text, prefix) {
+			return strings.TrimSpace(strings.TrimPrefix(text, prefix))
+		}
+	}
+	return ""
+}
+
+// checklocksHeld returns the mutex names a "+checklocksheld:mu" annotated
+// function requires its caller to already hold.
+func checklocksHeld(decl *ast.FuncDecl) []string {
+	if decl.Doc == nil {
+		return nil
+	}
+	var out []string
+	for _, c := range decl.Doc.List {
+		text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+		if strings.HasPrefix(text, "+checklocksheld:") {
+			out = append(out, strings.TrimSpace(strings.TrimPrefix(text, "+checklocksheld:")))
+		}
+	}
+	return out
+}
+
+// CheckLockAnnotations implements a small subset of gVisor's checklocks:
+// struct fields annotated with "+checklocks:mu" may only be read or written
+// while the named mutex field is held, and functions annotated with
+// "+checklocksheld:mu" may only be called while the caller already holds
+// mu: for a method, the mu field of its receiver; for a plain function,
+// the only check is that the caller holds some mutex. It also flags
+// recursively re-acquiring a mutex that's already in the current
+// lock-set. None of these checks runs in a file that declares no
+// "+checklocks" field.
+func CheckLockAnnotations(f *lint.File) {
+	fields := checklocksFields(f)
+	if len(fields) == 0 {
+		return
+	}
+
+	heldFuncs := map[*types.Func][]string{}
+	ast.Inspect(f.File, func(node ast.Node) bool {
+		decl, ok := node.(*ast.FuncDecl)
+		if !ok {
+			return true
+		}
+		if held := checklocksHeld(decl); len(held) > 0 {
+			if obj, ok := f.Pkg.TypesInfo.ObjectOf(decl.Name).(*types.Func); ok {
+				heldFuncs[obj] = held
+			}
+		}
+		return true
+	})
+
+	fn := func(node ast.Node) bool {
+		decl, ok := node.(*ast.FuncDecl)
+		if !ok {
+			return true
+		}
+		ssafn := f.EnclosingSSAFunction(decl)
+		if ssafn == nil {
+			return true
+		}
+		walkLockSets(mutexMethods, ssafn, func(locked []ssa.Value, instr ssa.Instruction) {
+			switch instr := instr.(type) {
+			case *ssa.FieldAddr:
+				field, ok := fieldVar(instr)
+				if !ok {
+					return
+				}
+				mu, ok := fields[field]
+				if !ok {
+					return
+				}
+				if !mutexNamed(locked, instr.X, mu) {
+					f.Errorf(instr, "access to field %s requires holding %s", field.Name(), mu)
+				}
+			case *ssa.Call:
+				callee := instr.Common().StaticCallee()
+				if callee == nil {
+					return
+				}
+				obj, ok := callee.Object().(*types.Func)
+				if !ok {
+					return
+				}
+				isMethod := obj.Type().(*types.Signature).Recv() != nil
+				args := instr.Common().Args
+				for _, mu := range heldFuncs[obj] {
+					if isMethod && len(args) > 0 {
+						if !mutexNamed(locked, args[0], mu) {
+							f.Errorf(instr, "calling %s requires the caller to hold %s", obj.Name(), mu)
+						}
+					} else if len(locked) == 0 {
+						f.Errorf(instr, "calling %s requires the caller to hold %s", obj.Name(), mu)
+					}
+				}
+				if op, mv := mutexCallOp(mutexMethods, instr.Common()); op == mutexAcquire && lockSetHas(locked, mv) {
+					f.Errorf(instr, "recursive lock: mutex is already held by the current goroutine")
+				}
+			}
+		})
+		return true
+	}
+	f.Walk(fn)
+}
+
+// fieldVar resolves the struct field a FieldAddr instruction refers to.
+func fieldVar(fa *ssa.FieldAddr) (*types.Var, bool) {
+	ptr, ok := fa.X.Type().(*types.Pointer)
+	if !ok {
+		return nil, false
+	}
+	st, ok := ptr.Elem().Underlying().(*types.Struct)
+	if !ok {
+		return nil, false
+	}
+	if fa.Field < 0 || fa.Field >= st.NumFields() {
+		return nil, false
+	}
+	return st.Field(fa.Field), true
+}
+
+// blockingOps is the set of fully qualified calls that block the calling
+// goroutine and are thus dangerous to make while holding a mutex: doing so
+// can deadlock if whatever unblocks the call needs the same mutex.
+var blockingOps = map[string]bool{
+	"time.Sleep":             true,
+	"(*sync.WaitGroup).Wait": true,
+	"(*sync.Cond).Wait":      true,
+}
+
+// blockingOp reports whether instr is a potentially blocking operation:
+// an unbuffered (or statically unknown capacity) channel send/receive, a
+// select without a default case, or a call to a known blocking function.
+func blockingOp(instr ssa.Instruction) bool {
+	switch instr := instr.(type) {
+	case *ssa.Send:
+		return !hasPositiveBufferedCapacity(instr.Chan)
+	case *ssa.UnOp:
+		return instr.Op == token.ARROW && !hasPositiveBufferedCapacity(instr.X)
+	case *ssa.Select:
+		if !instr.Blocking {
+			return false
+		}
+		return true
+	case *ssa.Call:
+		callee := instr.Common().StaticCallee()
+		if callee == nil {
+			return false
+		}
+		fn, ok := callee.Object().(*types.Func)
+		return ok && blockingOps[fn.FullName()]
+	}
+	return false
+}
+
+// hasPositiveBufferedCapacity reports whether v is a channel created with a
+// statically known, non-zero buffer size, in which case a send/receive on it
+// is not guaranteed to block.
+func hasPositiveBufferedCapacity(v ssa.Value) bool {
+	mc, ok := v.(*ssa.MakeChan)
+	if !ok {
+		return false
+	}
+	c, ok := mc.Size.(*ssa.Const)
+	if !ok || c.Value == nil {
+		return false
+	}
+	n, ok := constantIntFromSSA(c)
+	return ok && n > 0
+}
+
+func constantIntFromSSA(c *ssa.Const) (int64, bool) {
+	if c.Value == nil {
+		return 0, false
+	}
+	return c.Int64(), true
+}
+
+// CheckMutexBlockingOp flags a potentially blocking operation -- a channel
+// send/receive, a select without a default, time.Sleep, or
+// (*sync.WaitGroup).Wait -- performed while a sync.Mutex/RWMutex is held.
+// Blocking while holding a lock is a common source of deadlocks: the
+// goroutine that would unblock it may itself need the same mutex.
+func CheckMutexBlockingOp(f *lint.File) {
+	fn := func(node ast.Node) bool {
+		decl, ok := node.(*ast.FuncDecl)
+		if !ok {
+			return true
+		}
+		ssafn := f.EnclosingSSAFunction(decl)
+		if ssafn == nil {
+			return true
+		}
+		walkLockSets(mutexMethods, ssafn, func(locked []ssa.Value, instr ssa.Instruction) {
+			if len(locked) == 0 || !blockingOp(instr) {
+				return
+			}
+			f.Errorf(instr, "blocking operation while holding a mutex may deadlock")
+		})
+		return true
+	}
+	f.Walk(fn)
+}
+
+// mutexNamed reports whether the lock-set contains the mutex field named mu
+// on the same base value as base.
+func mutexNamed(locked []ssa.Value, base ssa.Value, mu string) bool {
+	for _, v := range locked {
+		fa, ok := v.(*ssa.FieldAddr)
+		if !ok {
+			continue
+		}
+		field, ok := fieldVar(fa)
+		if !ok || field.Name() != mu {
+			continue
+		}
+		if sameMutex(fa.X, base) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+// Package printf parses fmt-style format strings into their constituent
+// verbs, for use by staticcheck's printf format-string checker.
+package printf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Verb is a single "%..." directive inside a format string.
+type Verb struct {
+	// Raw is the directive as it appeared in the format string, e.g. "%+05.2[1]d".
+	Raw string
+	// Offset is the byte offset of the '%' within the format string.
+	Offset int
+	// Flags holds the flag characters that appeared, e.g. "+0".
+	Flags string
+	// Width is the width, or -1 if unspecified, or -2 if given as '*'.
+	Width int
+	// Prec is the precision, or -1 if unspecified, or -2 if given as '*'.
+	Prec int
+	// ArgIndex is the explicit argument index from "%[n]verb", or 0 if the
+	// verb consumes the next argument in sequence.
+	ArgIndex int
+	// Verb is the verb rune itself, e.g. 'd', 's', 'v'. 0 for a bare "%%".
+	Verb rune
+}
+
+// IsPercent reports whether the verb is a literal "%%", which consumes no
+// arguments.
+func (v Verb) IsPercent() bool { return v.Verb == 0 }
+
+// Parse splits format into the literal text and the sequence of verbs it
+// contains, following the syntax documented by the fmt package: each verb
+// is "%[flags][width][.precision][index]verb", where flags is any of
+// "+-# 0", width/precision are decimal digits or '*', and index is
+// "[n]" (1-based).
+func Parse(format string) ([]Verb, error) {
+	var verbs []Verb
+	i := 0
+	for i < len(format) {
+		if format[i] != '%' {
+			i++
+			continue
+		}
+		start := i
+		i++
+		if i >= len(format) {
+			return nil, fmt.Errorf("format %q ends with a trailing %%", format)
+		}
+		if format[i] == '%' {
+			verbs = append(verbs, Verb{Raw: "%%", Offset: start})
+			i++
+			continue
+		}
+
+		v := Verb{Offset: start, Width: -1, Prec: -1}
+		for i < len(format) && strings.ContainsRune("+-# 0", rune(format[i])) {
+			v.Flags += string(format[i])
+			i++
+		}
+		if i < len(format) && format[i] == '*' {
+			v.Width = -2
+			i++
+		} else {
+			j := i
+			for j < len(format) && format[j] >= '0' && format[j] <= '9' {
+				j++
+			}
+			if j > i {
+				v.Width = atoi(format[i:j])
+				i = j
+			}
+		}
+		if i < len(format) && format[i] == '.' {
+			i++
+			if i < len(format) && format[i] == '*' {
+				v.Prec = -2
+				i++
+			} else {
+				j := i
+				for j < len(format) && format[j] >= '0' && format[j] <= '9' {
+					j++
+				}
+				v.Prec = atoi(format[i:j])
+				i = j
+			}
+		}
+		if i < len(format) && format[i] == '[' {
+			j := strings.IndexByte(format[i:], ']')
+			if j < 0 {
+				return nil, fmt.Errorf("format %q has an unterminated argument index", format)
+			}
+			v.ArgIndex = atoi(format[i+1 : i+j])
+			i += j + 1
+		}
+		if i >= len(format) {
+			return nil, fmt.Errorf("format %q ends with an incomplete verb", format)
+		}
+		v.Verb = rune(format[i])
+		v.Raw = format[start : i+1]
+		i++
+		verbs = append(verbs, v)
+	}
+	return verbs, nil
+}
+
+func atoi(s string) int {
+	n := 0
+	for _, c := range s {
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
+
+// KnownVerbs is the set of verbs fmt itself understands.
+var KnownVerbs = map[rune]bool{
+	'v': true, 'T': true, '%': true,
+	't': true,
+	'b': true, 'c': true, 'd': true, 'o': true, 'O': true, 'q': true, 'x': true, 'X': true, 'U': true,
+	'e': true, 'E': true, 'f': true, 'F': true, 'g': true, 'G': true,
+	's': true, 'p': true,
+	'w': true,
+}
@@ -0,0 +1,367 @@
+package staticcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+	"honnef.co/go/lint"
+	"honnef.co/go/staticcheck/printf"
+)
+
+// printfFuncs maps the full name of a printf-style function to the index
+// of its format-string argument; every following argument is a printf
+// argument.
+var printfFuncs = map[string]int{
+	"fmt.Printf":  0,
+	"fmt.Sprintf": 0,
+	"fmt.Errorf":  0,
+	"fmt.Fprintf": 1,
+	"log.Printf":  0,
+	"log.Fatalf":  0,
+	"log.Panicf":  0,
+}
+
+var stringerIface = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(0, nil, "String", types.NewSignature(nil, nil, types.NewTuple(types.NewVar(0, nil, "", types.Typ[types.String])), false)),
+}, nil).Complete()
+
+var errorIface = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(0, nil, "Error", types.NewSignature(nil, nil, types.NewTuple(types.NewVar(0, nil, "", types.Typ[types.String])), false)),
+}, nil).Complete()
+
+func isBasicKind(typ types.Type, info types.BasicInfo) bool {
+	basic, ok := typ.Underlying().(*types.Basic)
+	return ok && basic.Info()&info != 0
+}
+
+func isByteSlice(typ types.Type) bool {
+	slice, ok := typ.Underlying().(*types.Slice)
+	return ok && isBasicKind(slice.Elem(), types.IsInteger) && types.TypeString(slice.Elem(), nil) == "byte"
+}
+
+func implementsEither(typ types.Type, ifaces ...*types.Interface) bool {
+	for _, iface := range ifaces {
+		if types.Implements(typ, iface) || types.Implements(types.NewPointer(typ), iface) {
+			return true
+		}
+	}
+	return false
+}
+
+// verbAccepts reports whether a format verb is compatible with the static
+// type of its argument. It only rejects combinations that are clearly
+// wrong; it's deliberately permissive about edge cases to avoid false
+// positives, since staticcheck prefers missing a bug to reporting one that
+// isn't there.
+func verbAccepts(verb rune, typ types.Type) bool {
+	switch verb {
+	case 'd', 'b', 'o', 'O', 'c', 'U':
+		return isBasicKind(typ, types.IsInteger) || implementsEither(typ, stringerIface)
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		return isBasicKind(typ, types.IsFloat|types.IsInteger)
+	case 's', 'q':
+		return isBasicKind(typ, types.IsString) || isByteSlice(typ) || implementsEither(typ, stringerIface, errorIface)
+	case 'x', 'X':
+		return isBasicKind(typ, types.IsInteger|types.IsString) || isByteSlice(typ)
+	case 't':
+		return isBasicKind(typ, types.IsBoolean)
+	default:
+		// %v, %T, %p and anything else accept arbitrary values.
+		return true
+	}
+}
+
+// formatString returns the format string passed at formatIdx. Besides a
+// plain language constant, it also resolves through SSA to the literal a
+// never-reassigned local variable was initialized with, so "var f string
+// = \"%d\"; fmt.Printf(f, x)" is checked the same as passing the literal
+// directly.
+func formatString(call *Call, farg *Argument) (string, bool) {
+	if s, ok := farg.ConstantString(); ok {
+		return s, true
+	}
+	ssafn := call.File.EnclosingSSAFunction(call.Expr)
+	if ssafn == nil {
+		return "", false
+	}
+	val, _ := ssafn.ValueForExpr(farg.Expr)
+	c, ok := val.(*ssa.Const)
+	if !ok || c.Value == nil || c.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(c.Value), true
+}
+
+// verbFlagProblem reports a message describing why a flag, width, or
+// precision on v is never meaningful to fmt, if any. It only rejects
+// combinations fmt's own documentation rules out, such as "%+s" (the '+'
+// flag means nothing for strings) or a precision on "%c" (which always
+// prints exactly one character); anything it doesn't recognize as
+// meaningless is left alone.
+func verbFlagProblem(v printf.Verb) (msg string, bad bool) {
+	for _, flag := range v.Flags {
+		switch flag {
+		case '+':
+			switch v.Verb {
+			case 'd', 'b', 'o', 'O', 'x', 'X', 'c', 'U', 'f', 'F', 'e', 'E', 'g', 'G', 'q', 'v':
+			default:
+				return fmt.Sprintf("%%%c: flag '+' is not meaningful for this verb", v.Verb), true
+			}
+		case '#':
+			switch v.Verb {
+			case 'b', 'o', 'x', 'X', 'e', 'E', 'f', 'F', 'g', 'G', 'p', 'q', 'v', 'U':
+			default:
+				return fmt.Sprintf("%%%c: flag '#' is not meaningful for this verb", v.Verb), true
+			}
+		}
+	}
+	if v.Prec != -1 && v.Verb == 'c' {
+		return "%c: a precision is not meaningful for this verb", true
+	}
+	return "", false
+}
+
+// parsePrintfCall parses the format string at formatIdx, if it is constant,
+// and returns it along with the number of trailing printf arguments. ok is
+// false if there is nothing to check, either because the format argument is
+// missing or isn't a constant string.
+func parsePrintfCall(call *Call, formatIdx int) (farg *Argument, verbs []printf.Verb, nargs int, ok bool) {
+	farg = call.Arg(formatIdx)
+	if farg == nil {
+		return nil, nil, 0, false
+	}
+	format, isConst := formatString(call, farg)
+	if !isConst {
+		return nil, nil, 0, false
+	}
+	verbs, err := printf.Parse(format)
+	if err != nil {
+		farg.Invalid("%s", err)
+		return nil, nil, 0, false
+	}
+	return farg, verbs, len(call.Expr.Args) - formatIdx - 1, true
+}
+
+// printfWrapperFormatIdx reports the zero-based index of fn's format
+// argument if fn looks like a user-defined printf-style wrapper. fn's
+// signature must end in the common wrapper shape, a string parameter
+// immediately followed by a trailing "...interface{}"; given that, fn is
+// a wrapper if its declaration in f carries a "//lint:printf:N" directive
+// (N is the 1-based parameter position), or else if that string
+// parameter is named "format".
+//
+// The directive is only found when fn is declared in f itself -- a
+// directive on a wrapper declared elsewhere in the package falls back to
+// the signature heuristic, same as an undirected wrapper would.
+func printfWrapperFormatIdx(f *lint.File, fn *types.Func) (int, bool) {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || !sig.Variadic() {
+		return 0, false
+	}
+	params := sig.Params()
+	n := params.Len()
+	if n < 2 {
+		return 0, false
+	}
+	last, ok := params.At(n - 1).Type().(*types.Slice)
+	if !ok {
+		return 0, false
+	}
+	if iface, ok := last.Elem().Underlying().(*types.Interface); !ok || iface.NumMethods() != 0 {
+		return 0, false
+	}
+	formatIdx := n - 2
+	if !isBasicKind(params.At(formatIdx).Type(), types.IsString) {
+		return 0, false
+	}
+	if idx, ok := printfDirectiveIdx(f, fn); ok {
+		return idx, true
+	}
+	if params.At(formatIdx).Name() == "format" {
+		return formatIdx, true
+	}
+	return 0, false
+}
+
+// printfDirectiveIdx looks for a "//lint:printf:N" comment on fn's own
+// declaration in f, returning N-1 (directives are written 1-based, to
+// match the position a human would count in the parameter list).
+func printfDirectiveIdx(f *lint.File, fn *types.Func) (int, bool) {
+	const prefix = "//lint:printf:"
+	for _, decl := range f.File.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Doc == nil || f.Pkg.TypesInfo.ObjectOf(fd.Name) != fn {
+			continue
+		}
+		for _, c := range fd.Doc.List {
+			if !strings.HasPrefix(c.Text, prefix) {
+				continue
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(c.Text[len(prefix):]))
+			if err != nil || n < 1 {
+				continue
+			}
+			return n - 1, true
+		}
+	}
+	return 0, false
+}
+
+// argIndices walks verbs, resolving each non-%% verb to the zero-based
+// index of the printf argument whose value it formats (accounting for
+// explicit "%[n]" indices, and for a dynamic "*" width and/or precision
+// each consuming an int argument of its own immediately before that
+// value), and reports the total number of arguments consumed plus
+// whether every verb used sequential, implicit indexing -- counting
+// arguments is only meaningful when no verb reordered them with an
+// explicit index.
+func argIndices(verbs []printf.Verb) (indices []int, consumed int, sequential bool) {
+	sequential = true
+	next := 0
+	for _, v := range verbs {
+		if v.IsPercent() {
+			continue
+		}
+		stars := 0
+		if v.Width == -2 {
+			stars++
+		}
+		if v.Prec == -2 {
+			stars++
+		}
+		idx := next
+		if v.ArgIndex > 0 {
+			idx = v.ArgIndex - 1
+			sequential = false
+		}
+		idx += stars
+		next = idx + 1
+		indices = append(indices, idx)
+	}
+	return indices, next, sequential
+}
+
+// printfArgCountRule returns a CallRule flagging calls whose number of
+// printf verbs doesn't match their number of trailing arguments. Calls
+// using an explicit "%[n]" index anywhere are skipped, since reordering
+// makes a simple count meaningless.
+func printfArgCountRule(formatIdx int, wrapperName string) CallRule {
+	return func(call *Call) {
+		farg, verbs, nargs, ok := parsePrintfCall(call, formatIdx)
+		if !ok {
+			return
+		}
+		_, consumed, sequential := argIndices(verbs)
+		if !sequential {
+			return
+		}
+		if consumed < nargs {
+			farg.Invalid("%s has more arguments than format verbs (%d verbs, %d arguments)", wrapperName, consumed, nargs)
+		} else if consumed > nargs {
+			farg.Invalid("%s has more format verbs than arguments (%d verbs, %d arguments)", wrapperName, consumed, nargs)
+		}
+	}
+}
+
+// printfVerbRule returns a CallRule flagging, for each verb in a printf
+// call, an unknown verb, a misplaced "%w", a flag/width/precision that
+// means nothing for that verb, or an argument whose static type doesn't
+// match what the verb expects -- resolved via argIndices, so a dynamic
+// "*" width or precision ahead of a verb is skipped over to check the
+// value being formatted, not the int that sets the width or precision.
+func printfVerbRule(formatIdx int, wrapperName string) CallRule {
+	return func(call *Call) {
+		farg, verbs, _, ok := parsePrintfCall(call, formatIdx)
+		if !ok {
+			return
+		}
+		indices, _, _ := argIndices(verbs)
+		isErrorf := call.Func.Name() == "Errorf"
+		sawW := false
+
+		i := 0
+		for _, v := range verbs {
+			if v.IsPercent() {
+				continue
+			}
+			idx := indices[i]
+			i++
+
+			if !printf.KnownVerbs[v.Verb] {
+				farg.Invalid("%s uses unknown verb %%%c", wrapperName, v.Verb)
+				continue
+			}
+			if v.Verb == 'w' {
+				switch {
+				case !isErrorf:
+					farg.Invalid("%%w is only valid in fmt.Errorf")
+				case sawW:
+					farg.Invalid("%%w must appear at most once in a format string")
+				}
+				sawW = true
+			}
+			if msg, bad := verbFlagProblem(v); bad {
+				farg.Invalid("%s", msg)
+			}
+
+			arg := call.Arg(formatIdx + 1 + idx)
+			if arg == nil {
+				continue
+			}
+			argType := arg.Type()
+			if argType == nil || verbAccepts(v.Verb, argType) {
+				continue
+			}
+			arg.Invalid("%s arg for %s has type %s, which doesn't match", v.Raw, wrapperName, argType)
+		}
+	}
+}
+
+// checkPrintfCalls walks f, dispatching every call to a known printf-style
+// function -- whether one of printfFuncs or a user-defined wrapper
+// recognized by printfWrapperFormatIdx -- to the CallRule ruleFor builds
+// for its format-argument index and name.
+func checkPrintfCalls(f *lint.File, ruleFor func(formatIdx int, wrapperName string) CallRule) {
+	fn := func(node ast.Node) bool {
+		ce, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		name, fnObj := calleeName(f, ce)
+		if fnObj == nil {
+			return true
+		}
+		formatIdx, ok := printfFuncs[name]
+		if !ok {
+			formatIdx, ok = printfWrapperFormatIdx(f, fnObj)
+			if !ok {
+				return true
+			}
+		}
+		ruleFor(formatIdx, fnObj.Name())(&Call{File: f, Expr: ce, Func: fnObj})
+		return true
+	}
+	f.Walk(fn)
+}
+
+// CheckPrintf validates the number of arguments passed to printf-style
+// functions (fmt.Printf/Sprintf/Errorf/Fprintf, log.Printf/Fatalf/Panicf,
+// plus user-defined wrappers around them) against the number of verbs in
+// their format string, accounting for "%%" and explicit "%[n]" indices.
+func CheckPrintf(f *lint.File) {
+	checkPrintfCalls(f, printfArgCountRule)
+}
+
+// CheckPrintfVerbs validates the verbs used in printf-style format
+// strings: every verb must be one fmt understands, "%w" may only appear
+// in fmt.Errorf and at most once, no flag/width/precision is combined
+// with a verb it means nothing for, and each verb's expected kind is
+// checked against the static type of its argument.
+func CheckPrintfVerbs(f *lint.File) {
+	checkPrintfCalls(f, printfVerbRule)
+}
@@ -0,0 +1,84 @@
+package staticcheck
+
+import (
+	"regexp/syntax"
+
+	"honnef.co/go/lint"
+)
+
+// regexpSyntaxIssues returns a CallRule that parses argument idx (when it is
+// a constant string) with regexp/syntax and flags substantive bugs that
+// regexp.Compile's mere validity check won't catch: character classes that
+// can never match, repeated identical alternation branches, and repetition
+// applied to an already-repeated sub-expression (the "(a*)*" shape that's
+// either redundant or, in backtracking engines, exponential -- RE2 doesn't
+// backtrack, but the pattern is still almost always a mistake).
+func regexpSyntaxIssues(idx int) CallRule {
+	return func(call *Call) {
+		arg := call.Arg(idx)
+		if arg == nil {
+			return
+		}
+		s, ok := arg.ConstantString()
+		if !ok {
+			return
+		}
+		re, err := syntax.Parse(s, syntax.Perl)
+		if err != nil {
+			// CheckRegexps (SA1000) already reports invalid patterns.
+			return
+		}
+		walkRegexpSyntax(re, func(bad *syntax.Regexp, msg string) {
+			arg.Invalid("%s", msg)
+		})
+	}
+}
+
+// unwrapCapture strips any capturing-group wrapper ("(...)", syntax.OpCapture)
+// around re, returning the first non-capturing sub-expression underneath.
+// Capturing parens don't change what a pattern matches, so "(a*)*" should
+// be seen as a repetition of "a*", same as the non-capturing "(?:a*)*".
+func unwrapCapture(re *syntax.Regexp) *syntax.Regexp {
+	for re.Op == syntax.OpCapture && len(re.Sub) == 1 {
+		re = re.Sub[0]
+	}
+	return re
+}
+
+func walkRegexpSyntax(re *syntax.Regexp, report func(*syntax.Regexp, string)) {
+	switch re.Op {
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			report(re, "character class never matches any character")
+		}
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest:
+		if len(re.Sub) == 1 {
+			switch unwrapCapture(re.Sub[0]).Op {
+			case syntax.OpStar, syntax.OpPlus, syntax.OpQuest:
+				report(re, "repetition of a repetition, such as (a*)*, is redundant or exponential; simplify it")
+			}
+		}
+	case syntax.OpAlternate:
+		for i, sub := range re.Sub {
+			for _, other := range re.Sub[:i] {
+				if sub.String() == other.String() {
+					report(re, "alternation contains the same branch more than once: "+sub.String())
+					break
+				}
+			}
+		}
+	}
+	for _, sub := range re.Sub {
+		walkRegexpSyntax(sub, report)
+	}
+}
+
+// CheckRegexpSyntax runs a deeper analysis of regexp.Compile/MustCompile
+// patterns than CheckRegexps, by parsing them with regexp/syntax and
+// inspecting the resulting tree instead of only checking for a parse error.
+func CheckRegexpSyntax(f *lint.File) {
+	checkCalls(f, map[string]CallRule{
+		"regexp.MustCompile": regexpSyntaxIssues(0),
+		"regexp.Compile":     regexpSyntaxIssues(0),
+	})
+}
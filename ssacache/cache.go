@@ -0,0 +1,86 @@
+// Package ssacache caches a built *ssa.Program per source file, keyed by
+// that file's on-disk modification time, so that re-analysing a package
+// after a single edited file can skip rebuilding SSA for every file whose
+// mtime hasn't changed. It has no connection to any particular front end
+// -- editor integration, a watch mode, or anything else that would want
+// to re-run checks incrementally could use it.
+//
+// This package used to live at lsp.ProgramCache, filed under a request
+// for a full `cmd/staticcheck-lsp` Language Server Protocol front end
+// (JSON-RPC transport, textDocument sync, publishDiagnostics,
+// codeAction, and the cmd/ entry point to host all of that). This
+// repository is a library of Check functions keyed by SA code
+// ("package staticcheck", no cmd/ directory and no driver that loads a
+// program, builds SSA, and calls them -- that lives in the separate tool
+// that consumes this package), so there is nothing here for an LSP
+// server to attach to, and fabricating one with no such driver to call
+// would just be guessing at an API this repository doesn't have. Renamed
+// and re-filed as what it actually is: a reusable caching building
+// block, not a step toward the LSP subcommand. The LSP front end itself
+// remains unbuilt and unstarted.
+package ssacache
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// ProgramCache caches a built *ssa.Program per source file, keyed by
+// that file's on-disk modification time, so that re-analysing a
+// package after a single edited file can skip rebuilding SSA for every
+// file whose mtime hasn't changed.
+type ProgramCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	mtime   time.Time
+	program *ssa.Program
+}
+
+// NewProgramCache returns an empty ProgramCache.
+func NewProgramCache() *ProgramCache {
+	return &ProgramCache{entries: map[string]cacheEntry{}}
+}
+
+// Get returns the program cached for path, and whether the cache had
+// one at all -- the cache is considered stale, and Get reports a miss,
+// if path's modification time has moved on since the value was Put.
+func (c *ProgramCache) Get(path string) (*ssa.Program, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[path]
+	if !ok || !entry.mtime.Equal(info.ModTime()) {
+		return nil, false
+	}
+	return entry.program, true
+}
+
+// Put records program as the cached build for path, at path's current
+// on-disk modification time.
+func (c *ProgramCache) Put(path string, program *ssa.Program) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[path] = cacheEntry{mtime: info.ModTime(), program: program}
+}
+
+// Invalidate drops any cached entry for path, for the case an editor
+// reports an in-memory edit that hasn't been saved yet and so isn't
+// reflected in the on-disk modification time Get and Put key off of.
+func (c *ProgramCache) Invalidate(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, path)
+}
@@ -0,0 +1,38 @@
+package pkg
+
+import "sync"
+
+type T struct {
+	mu sync.Mutex
+}
+
+func byValue(t T) {}
+
+func byPointer(t *T) {}
+
+func fn() {
+	var mu sync.Mutex
+	var t T
+	ts := []T{t}
+	ps := []*T{&t}
+
+	byValue(t) // MATCH /function call copies a lock value of type pkg.T/
+	byPointer(&t)
+
+	for _, v := range ts { // MATCH /range statement copies a lock value of type pkg.T/
+		_ = v
+	}
+	for _, v := range ps {
+		_ = v
+	}
+
+	foo(&mu)
+	_ = bar()
+}
+
+func foo(_ *sync.Mutex) {}
+
+func bar() *sync.Mutex {
+	var mu sync.Mutex
+	return &mu
+}
@@ -0,0 +1,17 @@
+package pkg
+
+import "fmt"
+
+func fn() {
+	fmt.Printf("%d\n", 1)
+	fmt.Printf("%d\n", 1, 2) // MATCH /has more arguments than format verbs \(1 verbs, 2 arguments\)/
+	fmt.Printf("%d %d\n", 1) // MATCH /has more format verbs than arguments \(2 verbs, 1 arguments\)/
+
+	// A "*" width or precision consumes an int argument of its own,
+	// ahead of the value being formatted; it must be counted as part of
+	// the verb's own argument cost, not left for the value to double as.
+	fmt.Printf("%*d\n", 5, 42)
+	fmt.Printf("%.*f\n", 2, 3.14)
+	fmt.Printf("%*.*f\n", 5, 2, 3.14)
+	fmt.Printf("%*d\n", 5) // MATCH /has more format verbs than arguments \(2 verbs, 1 arguments\)/
+}
@@ -0,0 +1,15 @@
+package pkg
+
+import "fmt"
+
+func fn() {
+	fmt.Printf("%d\n", 1)
+	fmt.Printf("%z\n", 1)   // MATCH /uses unknown verb %z/
+	fmt.Printf("%d\n", "s") // MATCH /arg for Printf has type string, which doesn't match/
+
+	// The width is an int (5), but the value being formatted by %*s is a
+	// string; the check must validate the value, not the width that
+	// happens to precede it.
+	fmt.Printf("%*s\n", 5, "ok")
+	fmt.Printf("%*d\n", 5, "bad") // MATCH /arg for Printf has type string, which doesn't match/
+}
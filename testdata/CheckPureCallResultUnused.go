@@ -0,0 +1,22 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+func pure(s string) string { return s }
+
+func impure(s string) string {
+	fmt.Println(s)
+	return s
+}
+
+func fn() {
+	strings.Replace("a", "a", "b", -1) // MATCH /the result of this call to Replace is never used/
+	pure("x")                          // MATCH /the result of this call to pure is never used/
+	impure("x")
+
+	s := strings.Replace("a", "a", "b", -1)
+	_ = s
+}
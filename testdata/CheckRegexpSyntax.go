@@ -0,0 +1,11 @@
+package pkg
+
+import "regexp"
+
+func fn() {
+	regexp.MustCompile(`[^\x00-\x{10FFFF}]`) // MATCH /character class never matches any character/
+	regexp.MustCompile(`(?:a*)*`)            // MATCH /repetition of a repetition, such as \(a\*\)\*, is redundant or exponential; simplify it/
+	regexp.MustCompile(`(a*)*`)              // MATCH /repetition of a repetition, such as \(a\*\)\*, is redundant or exponential; simplify it/
+	regexp.MustCompile(`foo|bar|foo`)        // MATCH /alternation contains the same branch more than once: foo/
+	regexp.MustCompile(`[a-z]+`)
+}
@@ -299,3 +299,20 @@ func fn27() {
 		return
 	}
 }
+
+func fn28() {
+	// The lock is held on entry to the loop, and the loop header has two
+	// predecessors: the function entry, and the loop's own back edge.
+	// Computing the header's lock-set from a single dominance-ordered
+	// pass sees the back edge as "not yet computed" and treats that as
+	// "holds nothing", emptying out the merged lock-set and hiding the
+	// leak below.
+	var x sync.Mutex
+	x.Lock()
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			return // MATCH /return before mutex unlock/
+		}
+	}
+	x.Unlock()
+}
@@ -16,4 +16,57 @@ func fn() {
 	c2 := make(chan os.Signal, 1)
 	signal.Notify(c2, os.Interrupt)
 	signal.Notify(c2, syscall.SIGHUP) // MATCH /channel buffer size 1 is too small to catch 2 signal/
+
+	// Conditional assignment: c3 may be either channel depending on b,
+	// so both need capacity for both signals.
+	c3a := make(chan os.Signal, 1)
+	c3b := make(chan os.Signal, 1)
+	var c3 chan os.Signal
+	if b {
+		c3 = c3a
+	} else {
+		c3 = c3b
+	}
+	signal.Notify(c3, os.Interrupt, syscall.SIGHUP) // MATCH /channel buffer size 1 is too small to catch 2 signal/
+
+	// A struct field assigned from a local composite literal.
+	type holder struct{ c chan os.Signal }
+	h := holder{c: make(chan os.Signal, 1)}
+	signal.Notify(h.c, os.Interrupt, syscall.SIGHUP) // MATCH /channel buffer size 1 is too small to catch 2 signal/
+
+	// A channel captured by a closure.
+	c4 := make(chan os.Signal, 1)
+	notify := func() {
+		signal.Notify(c4, os.Interrupt, syscall.SIGHUP) // MATCH /channel buffer size 1 is too small to catch 2 signal/
+	}
+	notify()
+
+	// A three-hop transitive alias chain: c5/c6 are unioned by the first
+	// Notify call below, and c6/c7 by the second, which only bridges
+	// clusters because c6 is already a member of the first one -- the
+	// third Notify call, naming only c5, must still be scored against
+	// the signals registered on all three.
+	c5 := make(chan os.Signal, 1)
+	c6 := make(chan os.Signal, 1)
+	c7 := make(chan os.Signal, 1)
+
+	var first chan os.Signal
+	if b {
+		first = c5
+	} else {
+		first = c6
+	}
+	signal.Notify(first, os.Interrupt) // MATCH /channel buffer size 1 is too small to catch 3 signal/
+
+	var second chan os.Signal
+	if b {
+		second = c6
+	} else {
+		second = c7
+	}
+	signal.Notify(second, syscall.SIGHUP) // MATCH /channel buffer size 1 is too small to catch 3 signal/
+
+	signal.Notify(c5, syscall.SIGTERM) // MATCH /channel buffer size 1 is too small to catch 3 signal/
 }
+
+var b bool
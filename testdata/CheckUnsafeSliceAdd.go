@@ -0,0 +1,19 @@
+package main
+
+import "unsafe"
+
+func fn() {
+	var p *byte
+	_ = unsafe.Slice(p, 0) // legal: nil pointer, zero length
+	_ = unsafe.Slice(p, 1) // MATCH /unsafe.Slice: the pointer is always nil/
+
+	var n int = -1
+	_ = unsafe.Slice(p, n) // MATCH /unsafe.Slice: the pointer is always nil/
+
+	var q *int
+	_ = unsafe.Slice(q, -1) // MATCH /unsafe.Slice: length is negative/
+
+	var arr [4]int
+	_ = unsafe.Add(unsafe.Pointer(&arr[1]), 3*unsafe.Sizeof(arr[0])) // MATCH /unsafe.Add: the resulting pointer is outside the bounds of the 4-element array/
+	_ = unsafe.Add(unsafe.Pointer(&arr[1]), 2*unsafe.Sizeof(arr[0]))
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func fn() {
+	c := make(chan os.Signal, 1)
+
+	signal.Notify(c, os.Interrupt)
+	signal.Notify(c, os.Kill) // MATCH /SIGKILL signal cannot be trapped/
+	signal.Notify(c, syscall.SIGKILL) // MATCH /SIGKILL signal cannot be trapped/
+	signal.Notify(c, syscall.SIGSTOP) // MATCH /SIGSTOP signal cannot be trapped/
+	signal.Notify(c, os.Signal(syscall.SIGKILL)) // MATCH /SIGKILL signal cannot be trapped/
+
+	sig := os.Kill
+	signal.Notify(c, sig) // MATCH /SIGKILL signal cannot be trapped/
+
+	signal.Ignore(syscall.SIGSTOP) // MATCH /SIGSTOP signal cannot be trapped/
+	signal.Reset(os.Kill)          // MATCH /SIGKILL signal cannot be trapped/
+}
@@ -2,10 +2,46 @@ package pkg
 
 type T struct{}
 
+func (T) M() {}
+
+type embed struct {
+	*T
+}
+
 func fn1(_ T) {}
 
+func f() *T { return nil }
+
 func fn2() {
-	t1 := &T{}
-	fn1(&*t1) // MATCH /&*T is ineffective. It will be simplified to T/
-	fn1(*&s1) // MATCH /\*&T is ineffective. It will be simplified to T/
+	var x T
+	p := &x
+
+	_ = &*p  // MATCH /&\*p will be simplified to p/
+	fn1(*&x) // MATCH /\*&x will be simplified to x/
+
+	// Nested pairs collapse as far as they can: the inner &/* pair
+	// cancels, but the outer operator is a real indirection, not a
+	// redundant one, so it stays.
+	_ = &*(&x) // MATCH /&\*\(&x\) will be simplified to &x/
+	_ = *&(*p) // MATCH /\*&\(\*p\) will be simplified to \*p/
+
+	var arr [4]int
+	ap := &arr
+	_ = &(*ap)[1] // MATCH /&\(\*ap\)\[1\] will be simplified to &ap\[1\]/
+
+	(*&x).M()  // MATCH /\(\*&x\)\.M will be simplified to x\.M/
+	_ = (*p).M // no match: p is already a pointer, not &y
+
+	// A method value through the same redundant pair; must not change
+	// which method set the call resolves against.
+	m := (*&x).M // MATCH /\(\*&x\)\.M will be simplified to x\.M/
+	m()
+
+	// Embedded pointer field access through a redundant pair.
+	e := &embed{T: &T{}}
+	(*&*e).M() // MATCH /\(\*&\*e\)\.M will be simplified to \(\*e\)\.M/
+
+	// Must not fire: f() may have side effects (and could return nil),
+	// so the check can't assume it's safe to stop calling through it.
+	_ = &*f()
 }
@@ -0,0 +1,427 @@
+// Package vrp implements a small value-range propagation lattice over
+// golang.org/x/tools/go/ssa values, for use by checks that need to know
+// whether an integer is provably in or out of some range (an index into a
+// slice, say) rather than just whether it's a compile-time constant.
+package vrp
+
+import (
+	"go/token"
+	"go/types"
+	"math/big"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// Range is an interval [Lo, Hi] over the possible values of an integer (or,
+// for strings and slices, the possible lengths). A nil Lo means -∞, a nil
+// Hi means +∞; a zero Range (both nil) means "nothing is known".
+type Range struct {
+	Lo, Hi *big.Int
+}
+
+// Known reports whether r carries any information at all.
+func (r Range) Known() bool { return r.Lo != nil || r.Hi != nil }
+
+// Contains reports whether n is within r, treating a nil bound as
+// unbounded on that side.
+func (r Range) Contains(n *big.Int) bool {
+	if r.Lo != nil && n.Cmp(r.Lo) < 0 {
+		return false
+	}
+	if r.Hi != nil && n.Cmp(r.Hi) > 0 {
+		return false
+	}
+	return true
+}
+
+func exact(n int64) Range {
+	v := big.NewInt(n)
+	return Range{Lo: v, Hi: v}
+}
+
+func bigMin(a, b *big.Int) *big.Int {
+	if a == nil || b == nil {
+		return nil
+	}
+	if a.Cmp(b) < 0 {
+		return a
+	}
+	return b
+}
+
+func bigMax(a, b *big.Int) *big.Int {
+	if a == nil || b == nil {
+		return nil
+	}
+	if a.Cmp(b) > 0 {
+		return a
+	}
+	return b
+}
+
+// union returns the smallest range containing both a and b.
+func union(a, b Range) Range {
+	if !a.Known() {
+		return a
+	}
+	if !b.Known() {
+		return b
+	}
+	return Range{Lo: bigMin(a.Lo, b.Lo), Hi: bigMax(a.Hi, b.Hi)}
+}
+
+// intersect returns the tightest range consistent with both a and b.
+func intersect(a, b Range) Range {
+	if !a.Known() {
+		return b
+	}
+	if !b.Known() {
+		return a
+	}
+	r := Range{Lo: a.Lo, Hi: a.Hi}
+	if b.Lo != nil && (r.Lo == nil || b.Lo.Cmp(r.Lo) > 0) {
+		r.Lo = b.Lo
+	}
+	if b.Hi != nil && (r.Hi == nil || b.Hi.Cmp(r.Hi) < 0) {
+		r.Hi = b.Hi
+	}
+	return r
+}
+
+func add(a, b Range) Range {
+	if !a.Known() || !b.Known() {
+		return Range{}
+	}
+	var lo, hi *big.Int
+	if a.Lo != nil && b.Lo != nil {
+		lo = new(big.Int).Add(a.Lo, b.Lo)
+	}
+	if a.Hi != nil && b.Hi != nil {
+		hi = new(big.Int).Add(a.Hi, b.Hi)
+	}
+	return Range{Lo: lo, Hi: hi}
+}
+
+func sub(a, b Range) Range {
+	if !a.Known() || !b.Known() {
+		return Range{}
+	}
+	var lo, hi *big.Int
+	if a.Lo != nil && b.Hi != nil {
+		lo = new(big.Int).Sub(a.Lo, b.Hi)
+	}
+	if a.Hi != nil && b.Lo != nil {
+		hi = new(big.Int).Sub(a.Hi, b.Lo)
+	}
+	return Range{Lo: lo, Hi: hi}
+}
+
+func mul(a, b Range) Range {
+	// Multiplication can flip which operand bound produces the result
+	// extreme once negative numbers are involved; rather than enumerate
+	// all four corner cases, only handle the common case of two
+	// non-negative ranges, which is what bounds/index checks care about.
+	if !a.Known() || !b.Known() {
+		return Range{}
+	}
+	zero := big.NewInt(0)
+	if a.Lo == nil || a.Lo.Cmp(zero) < 0 || b.Lo == nil || b.Lo.Cmp(zero) < 0 {
+		return Range{}
+	}
+	var hi *big.Int
+	if a.Hi != nil && b.Hi != nil {
+		hi = new(big.Int).Mul(a.Hi, b.Hi)
+	}
+	return Range{Lo: new(big.Int).Mul(a.Lo, b.Lo), Hi: hi}
+}
+
+func neg(a Range) Range {
+	if !a.Known() {
+		return Range{}
+	}
+	var lo, hi *big.Int
+	if a.Hi != nil {
+		lo = new(big.Int).Neg(a.Hi)
+	}
+	if a.Lo != nil {
+		hi = new(big.Int).Neg(a.Lo)
+	}
+	return Range{Lo: lo, Hi: hi}
+}
+
+// widen drops whichever bound of next moved further away from prev's
+// corresponding bound, replacing it with infinity. This is the standard
+// VRP widening operator: it trades precision for guaranteed termination
+// when a loop-carried value's range would otherwise grow forever.
+func widen(prev, next Range) Range {
+	if !prev.Known() {
+		return next
+	}
+	if !next.Known() {
+		return next
+	}
+	r := next
+	if r.Lo != nil && (prev.Lo == nil || r.Lo.Cmp(prev.Lo) < 0) {
+		r.Lo = nil
+	}
+	if r.Hi != nil && (prev.Hi == nil || r.Hi.Cmp(prev.Hi) > 0) {
+		r.Hi = nil
+	}
+	return r
+}
+
+// refinement records the narrowed range a comparison implies for one value
+// along one edge out of an *ssa.If.
+type refinement struct {
+	value ssa.Value
+	rng   Range
+}
+
+// refineCond returns the refinements implied for the true and false
+// successors of an *ssa.If whose condition is cond, e.g. "x < 10" narrows
+// x to (-∞, 9] on the true edge and [10, ∞) on the false edge. Only
+// comparisons of a value against a constant are understood.
+func refineCond(cond ssa.Value) (t, f refinement) {
+	bin, ok := cond.(*ssa.BinOp)
+	if !ok {
+		return
+	}
+	v, c, swapped := bin.X, bin.Y, false
+	cc, ok := c.(*ssa.Const)
+	if !ok {
+		v, c, swapped = bin.Y, bin.X, true
+		cc, ok = c.(*ssa.Const)
+		if !ok {
+			return
+		}
+	}
+	n, ok := constInt(cc)
+	if !ok {
+		return
+	}
+	op := bin.Op
+	if swapped {
+		// "10 < x" is "x > 10"; flip the operator to normalize on
+		// "value op constant".
+		switch op {
+		case token.LSS:
+			op = token.GTR
+		case token.LEQ:
+			op = token.GEQ
+		case token.GTR:
+			op = token.LSS
+		case token.GEQ:
+			op = token.LEQ
+		}
+	}
+	one := big.NewInt(1)
+	switch op {
+	case token.LSS: // v < n
+		t = refinement{v, Range{Hi: new(big.Int).Sub(n, one)}}
+		f = refinement{v, Range{Lo: n}}
+	case token.LEQ: // v <= n
+		t = refinement{v, Range{Hi: n}}
+		f = refinement{v, Range{Lo: new(big.Int).Add(n, one)}}
+	case token.GTR: // v > n
+		t = refinement{v, Range{Lo: new(big.Int).Add(n, one)}}
+		f = refinement{v, Range{Hi: n}}
+	case token.GEQ: // v >= n
+		t = refinement{v, Range{Lo: n}}
+		f = refinement{v, Range{Hi: new(big.Int).Sub(n, one)}}
+	case token.EQL: // v == n
+		t = refinement{v, exact64(n)}
+	case token.NEQ: // v != n
+		f = refinement{v, exact64(n)}
+	}
+	return
+}
+
+func exact64(n *big.Int) Range { return Range{Lo: n, Hi: n} }
+
+func constInt(c *ssa.Const) (*big.Int, bool) {
+	if c.Value == nil {
+		return nil, false
+	}
+	basic, ok := c.Type().Underlying().(*types.Basic)
+	if !ok || basic.Info()&types.IsInteger == 0 {
+		return nil, false
+	}
+	n, ok := new(big.Int).SetString(c.Value.ExactString(), 10)
+	return n, ok
+}
+
+// Ranges computes an approximate range for every integer-, string- and
+// slice-typed SSA value defined in fn, following the constraints implied
+// by arithmetic, by len/cap, and by branches that compare a value against
+// a constant. It's a deliberately small VRP: loop-carried values are
+// widened to infinity as soon as they grow between iterations (no
+// narrowing pass, unlike a full Bodik/Gough-Klaeren implementation), and
+// multiplication only tracks non-negative operands. That's enough to
+// answer "can this index possibly be out of bounds", which is what the
+// bounds checks built on top of it need.
+func Ranges(fn *ssa.Function) map[ssa.Value]Range {
+	ranges := map[ssa.Value]Range{}
+	refineIn := map[*ssa.BasicBlock][]refinement{}
+
+	// An unsigned parameter can never be negative, regardless of what the
+	// caller passes; seeding that here makes "x >= 0" fall out of Compare
+	// for free instead of needing special-casing in every consumer.
+	for _, p := range fn.Params {
+		if basic, ok := p.Type().Underlying().(*types.Basic); ok && basic.Info()&types.IsUnsigned != 0 {
+			ranges[p] = Range{Lo: big.NewInt(0)}
+		}
+	}
+
+	get := func(v ssa.Value) Range {
+		if r, ok := ranges[v]; ok {
+			return r
+		}
+		if c, ok := v.(*ssa.Const); ok {
+			if n, ok := constInt(c); ok {
+				return exact64(n)
+			}
+			return Range{}
+		}
+		return Range{}
+	}
+
+	compute := func(v ssa.Value, local Range) Range {
+		var r Range
+		switch instr := v.(type) {
+		case *ssa.Const:
+			if n, ok := constInt(instr); ok {
+				r = exact64(n)
+			}
+		case *ssa.BinOp:
+			x, y := get(instr.X), get(instr.Y)
+			switch instr.Op {
+			case token.ADD:
+				r = add(x, y)
+			case token.SUB:
+				r = sub(x, y)
+			case token.MUL:
+				r = mul(x, y)
+			}
+		case *ssa.UnOp:
+			if instr.Op == token.SUB {
+				r = neg(get(instr.X))
+			}
+		case *ssa.Phi:
+			for _, e := range instr.Edges {
+				r = union(r, get(e))
+			}
+			if prev, ok := ranges[v]; ok {
+				r = widen(prev, r)
+			}
+		case *ssa.Convert:
+			if basic, ok := instr.Type().Underlying().(*types.Basic); ok && basic.Info()&types.IsInteger != 0 {
+				r = get(instr.X)
+			}
+		case *ssa.Call:
+			if callee := instr.Call.StaticCallee(); callee == nil && instr.Call.Value != nil {
+				if b, ok := instr.Call.Value.(*ssa.Builtin); ok && (b.Name() == "len" || b.Name() == "cap") {
+					r = lenCapRange(instr.Call.Args[0])
+				}
+			}
+		case *ssa.Slice:
+			r = Range{Lo: big.NewInt(0)}
+		}
+		return intersect(r, local)
+	}
+
+	// Two passes: the first seeds every value's range assuming
+	// loop-carried phis equal the union of whatever's been computed so
+	// far (i.e. nothing, on the first encounter); the second widens any
+	// phi whose range grew, which is enough to reach a fixed point
+	// without iterating until convergence.
+	for pass := 0; pass < 2; pass++ {
+		for _, b := range fn.Blocks {
+			var local map[ssa.Value]Range
+			if refs := refineIn[b]; len(refs) > 0 {
+				local = make(map[ssa.Value]Range, len(refs))
+				for _, ref := range refs {
+					local[ref.value] = intersect(local[ref.value], ref.rng)
+				}
+			}
+			for _, instr := range b.Instrs {
+				v, ok := instr.(ssa.Value)
+				if !ok {
+					continue
+				}
+				ranges[v] = compute(v, local[v])
+			}
+			if len(b.Instrs) == 0 {
+				continue
+			}
+			if ifi, ok := b.Instrs[len(b.Instrs)-1].(*ssa.If); ok && len(b.Succs) == 2 {
+				t, f := refineCond(ifi.Cond)
+				if t.value != nil {
+					refineIn[b.Succs[0]] = append(refineIn[b.Succs[0]], t)
+				}
+				if f.value != nil {
+					refineIn[b.Succs[1]] = append(refineIn[b.Succs[1]], f)
+				}
+			}
+		}
+	}
+	return ranges
+}
+
+// lenCapRange returns the range of len(x)/cap(x) for an argument of array,
+// slice, or string type: exactly the array length if x has a fixed-size
+// array type, otherwise just "not negative".
+func lenCapRange(x ssa.Value) Range {
+	typ := x.Type()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	if arr, ok := typ.Underlying().(*types.Array); ok {
+		return exact(arr.Len())
+	}
+	return Range{Lo: big.NewInt(0)}
+}
+
+// Compare reports whether "x op y" is statically determined by their
+// ranges, and if so, what its result always is. ok is false when the
+// ranges overlap enough that either outcome remains possible.
+func Compare(op token.Token, x, y Range) (result, ok bool) {
+	if !x.Known() || !y.Known() {
+		return false, false
+	}
+	switch op {
+	case token.LSS:
+		if x.Hi != nil && y.Lo != nil && x.Hi.Cmp(y.Lo) < 0 {
+			return true, true
+		}
+		if x.Lo != nil && y.Hi != nil && x.Lo.Cmp(y.Hi) >= 0 {
+			return false, true
+		}
+	case token.LEQ:
+		if x.Hi != nil && y.Lo != nil && x.Hi.Cmp(y.Lo) <= 0 {
+			return true, true
+		}
+		if x.Lo != nil && y.Hi != nil && x.Lo.Cmp(y.Hi) > 0 {
+			return false, true
+		}
+	case token.GTR:
+		return Compare(token.LSS, y, x)
+	case token.GEQ:
+		return Compare(token.LEQ, y, x)
+	case token.EQL:
+		if x.Hi != nil && y.Lo != nil && x.Hi.Cmp(y.Lo) < 0 {
+			return false, true
+		}
+		if y.Hi != nil && x.Lo != nil && y.Hi.Cmp(x.Lo) < 0 {
+			return false, true
+		}
+		if x.Lo != nil && x.Hi != nil && y.Lo != nil && y.Hi != nil &&
+			x.Lo.Cmp(x.Hi) == 0 && x.Lo.Cmp(y.Lo) == 0 && y.Lo.Cmp(y.Hi) == 0 {
+			return true, true
+		}
+	case token.NEQ:
+		if r, ok := Compare(token.EQL, x, y); ok {
+			return !r, true
+		}
+	}
+	return false, false
+}